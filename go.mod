@@ -4,10 +4,15 @@ go 1.23.4
 
 require (
 	github.com/coreos/go-systemd/v22 v22.5.0
+	// The down reason of peers is read from the "Peer Down" message GoBGP
+	// logs with its Key and Reason fields, see pkg/speaker/peers.go. Run
+	// TestPeerDownReason when upgrading.
 	github.com/osrg/gobgp/v3 v3.36.0
 	github.com/prometheus/client_golang v1.23.2
 	github.com/rhgb/gobfd v0.0.0-20210411151426-aba5cf6ebe30
 	github.com/robfig/cron/v3 v3.0.1
+	github.com/vishvananda/netlink v1.2.1
+	github.com/vishvananda/netns v0.0.4
 	go.uber.org/zap v1.27.0
 	golang.org/x/sys v0.35.0
 	google.golang.org/grpc v1.56.3
@@ -41,8 +46,6 @@ require (
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/spf13/viper v1.16.0 // indirect
 	github.com/subosito/gotenv v1.4.2 // indirect
-	github.com/vishvananda/netlink v1.2.1 // indirect
-	github.com/vishvananda/netns v0.0.4 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
 	go.yaml.in/yaml/v2 v2.4.2 // indirect
 	golang.org/x/net v0.43.0 // indirect
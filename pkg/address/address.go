@@ -0,0 +1,49 @@
+// Package address adds and removes the anycast addresses of prefixes on
+// local interfaces and looks up the addresses of interfaces.
+package address
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// maxNameLen is the longest interface name Linux accepts (IFNAMSIZ - 1).
+const maxNameLen = 15
+
+// ValidateName returns an error when name cannot be a network interface name.
+func ValidateName(name string) error {
+	if name == "" || len(name) > maxNameLen || name == "." || name == ".." || strings.ContainsAny(name, "/: \t\n") {
+		return fmt.Errorf("must be an interface name of at most %d characters without '/', ':' or spaces, got %q", maxNameLen, name)
+	}
+	return nil
+}
+
+// Primary returns the first address of interface name in the IPv6 family
+// when ipv6 is set, or else in the IPv4 family. Link-local IPv6 addresses are
+// skipped.
+func Primary(name string, ipv6 bool) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s: %w", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("addresses of %s: %w", name, err)
+	}
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP
+		if (ip.To4() == nil) == ipv6 && !ip.IsLinkLocalUnicast() {
+			return ip, nil
+		}
+	}
+	family := "IPv4"
+	if ipv6 {
+		family = "IPv6"
+	}
+	return nil, fmt.Errorf("no %s address on %s", family, name)
+}
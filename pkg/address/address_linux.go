@@ -0,0 +1,54 @@
+//go:build linux
+// +build linux
+
+package address
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Add adds prefix, in CIDR notation, to the interface named name. Adding an
+// address already present succeeds.
+func Add(name, prefix string) error {
+	link, addr, err := parse(name, prefix)
+	if err != nil {
+		return err
+	}
+	if addr.IP.To4() == nil {
+		// Anycast addresses are expected on several hosts.
+		addr.Flags = unix.IFA_F_NODAD
+	}
+	if err := netlink.AddrReplace(link, addr); err != nil {
+		return fmt.Errorf("add %s to %s: %w", prefix, name, err)
+	}
+	return nil
+}
+
+// Delete removes prefix, in CIDR notation, from the interface named name.
+// Removing an address not present succeeds.
+func Delete(name, prefix string) error {
+	link, addr, err := parse(name, prefix)
+	if err != nil {
+		return err
+	}
+	if err := netlink.AddrDel(link, addr); err != nil && !errors.Is(err, unix.EADDRNOTAVAIL) {
+		return fmt.Errorf("remove %s from %s: %w", prefix, name, err)
+	}
+	return nil
+}
+
+func parse(name, prefix string) (netlink.Link, *netlink.Addr, error) {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("interface %s: %w", name, err)
+	}
+	addr, err := netlink.ParseAddr(prefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("address %s: %w", prefix, err)
+	}
+	return link, addr, nil
+}
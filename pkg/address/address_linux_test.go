@@ -0,0 +1,119 @@
+//go:build linux
+// +build linux
+
+package address
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+// withDummy runs f in a new network namespace holding an up dummy interface
+// named dummy0.
+func withDummy(t *testing.T, f func(t *testing.T)) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("requires root to create a network namespace")
+	}
+	// Namespaces are per thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	origin, err := netns.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer origin.Close()
+	ns, err := netns.New()
+	if err != nil {
+		t.Skipf("cannot create a network namespace: %v", err)
+	}
+	defer ns.Close()
+	defer func() {
+		if err := netns.Set(origin); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	dummy := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "dummy0"}}
+	if err := netlink.LinkAdd(dummy); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			t.Skip("dummy interfaces are not supported")
+		}
+		t.Fatal(err)
+	}
+	if err := netlink.LinkSetUp(dummy); err != nil {
+		t.Fatal(err)
+	}
+	f(t)
+}
+
+// find returns the address prefix of dummy0, or nil when it is not present.
+func find(t *testing.T, prefix string) *netlink.Addr {
+	t.Helper()
+	link, err := netlink.LinkByName("dummy0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range addrs {
+		if a.IPNet.String() == prefix {
+			return &a
+		}
+	}
+	return nil
+}
+
+func TestAddDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		wantFlag int
+	}{
+		{name: "ipv4", prefix: "198.51.100.1/32"},
+		{name: "ipv6", prefix: "2001:db8::1/128", wantFlag: unix.IFA_F_NODAD},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDummy(t, func(t *testing.T) {
+				for range 2 {
+					if err := Add("dummy0", tt.prefix); err != nil {
+						t.Fatal(err)
+					}
+					addr := find(t, tt.prefix)
+					if addr == nil {
+						t.Fatalf("%s not added", tt.prefix)
+					}
+					if addr.Flags&tt.wantFlag != tt.wantFlag {
+						t.Errorf("flags = %#x, want %#x set", addr.Flags, tt.wantFlag)
+					}
+				}
+
+				for range 2 {
+					if err := Delete("dummy0", tt.prefix); err != nil {
+						t.Fatal(err)
+					}
+					if find(t, tt.prefix) != nil {
+						t.Fatalf("%s not deleted", tt.prefix)
+					}
+				}
+			})
+		})
+	}
+
+	t.Run("missing interface", func(t *testing.T) {
+		withDummy(t, func(t *testing.T) {
+			if err := Add("missing0", "198.51.100.1/32"); err == nil {
+				t.Error("Add succeeded on a missing interface")
+			}
+		})
+	})
+}
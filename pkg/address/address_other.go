@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package address
+
+import "fmt"
+
+// Add returns an error on non-Linux systems.
+func Add(name, prefix string) error {
+	return fmt.Errorf("managing interface addresses is only supported on Linux systems")
+}
+
+// Delete returns an error on non-Linux systems.
+func Delete(name, prefix string) error {
+	return fmt.Errorf("managing interface addresses is only supported on Linux systems")
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DefaultConfigPath is used when --config is not given.
+const DefaultConfigPath = "config.yaml"
+
+// command is a herald subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer, info BuildInfo) error
+}
+
+func commands() []command {
+	return []command{
+		{name: "run", summary: "Start the BGP speaker and health probes", run: runCommand},
+		{name: "validate", summary: "Parse and check a configuration file without starting BGP", run: validateCommand},
+		{name: "import", summary: "Convert an ExaBGP configuration to herald (import exabgp)", run: importCommand},
+		{name: "schema", summary: "Print the JSON Schema of the configuration file", run: schemaCommand},
+		{name: "version", summary: "Print version and build information", run: versionCommand},
+	}
+}
+
+// errUsage is returned when the command line is invalid and usage has
+// already been printed.
+var errUsage = errors.New("usage error")
+
+// Run parses args (without the program name) and executes the selected
+// subcommand. It returns the process exit code.
+//
+// For compatibility with existing unit files, a command line starting with a
+// flag (e.g. "herald --config /etc/herald/config.yaml") is treated as "run",
+// and "--version" as "version".
+func Run(args []string, info BuildInfo) int {
+	return run(args, os.Stdout, os.Stderr, info)
+}
+
+func run(args []string, stdout, stderr io.Writer, info BuildInfo) int {
+	name := "run"
+	if len(args) > 0 {
+		switch {
+		case args[0] == "--version" || args[0] == "-version" || args[0] == "-v":
+			name, args = "version", args[1:]
+		case args[0] == "help" || args[0] == "--help" || args[0] == "-help" || args[0] == "-h":
+			usage(stdout)
+			return 0
+		case !strings.HasPrefix(args[0], "-"):
+			name, args = args[0], args[1:]
+		}
+	}
+
+	for _, c := range commands() {
+		if c.name != name {
+			continue
+		}
+		if err := c.run(args, stdout, stderr, info); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			if errors.Is(err, errUsage) {
+				return 2
+			}
+			fmt.Fprintf(stderr, "herald %s: %v\n", name, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "herald: unknown command %q\n\n", name)
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: herald <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'herald <command> --help' for the flags of a command.")
+}
+
+// newFlagSet returns a FlagSet for a subcommand that reports errors on stderr
+// instead of exiting.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("herald "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags parses args and rejects unexpected positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		// The FlagSet has already reported the error and printed usage.
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected argument %q\n", fs.Arg(0))
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
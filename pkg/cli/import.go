@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ahmet2mir/herald/pkg/exabgp"
+)
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ", ") }
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func importCommand(args []string, stdout, stderr io.Writer, info BuildInfo) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(stderr, "Usage: herald import exabgp [flags]")
+		return errUsage
+	}
+	if args[0] != "exabgp" {
+		fmt.Fprintf(stderr, "herald import: unknown format %q, supported formats: exabgp\n", args[0])
+		return errUsage
+	}
+	return importExaBGP(args[1:], stdout, stderr)
+}
+
+func importExaBGP(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("import exabgp", stderr)
+	configPath := fs.String("config", "", "path to the ExaBGP configuration file (e.g. /etc/exabgp/exabgp.conf)")
+	var healthchecks stringsFlag
+	fs.Var(&healthchecks, "healthcheck", "arguments of an exabgp-healthcheck run outside of the ExaBGP configuration (repeatable)")
+	output := fs.String("output", "", "write the herald configuration to this file instead of stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *configPath == "" && len(healthchecks) == 0 {
+		fmt.Fprintln(stderr, "--config or --healthcheck is required")
+		fs.Usage()
+		return errUsage
+	}
+
+	var data []byte
+	source := "exabgp-healthcheck arguments"
+	if *configPath != "" {
+		var err error
+		data, err = os.ReadFile(filepath.Clean(*configPath))
+		if err != nil {
+			return fmt.Errorf("failed to read ExaBGP configuration: %w", err)
+		}
+		source = *configPath
+	}
+	var healthcheckArgs [][]string
+	for _, h := range healthchecks {
+		hargs, err := exabgp.SplitArgs(h)
+		if err != nil {
+			return fmt.Errorf("--healthcheck: %w", err)
+		}
+		healthcheckArgs = append(healthcheckArgs, hargs)
+	}
+
+	result, err := exabgp.Import(source, data, healthcheckArgs)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteYAML(&buf, source); err != nil {
+		return err
+	}
+	if *output != "" {
+		if err := os.WriteFile(*output, buf.Bytes(), 0o600); err != nil {
+			return fmt.Errorf("failed to write herald configuration: %w", err)
+		}
+	} else if _, err := stdout.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	for _, w := range result.Warnings {
+		fmt.Fprintf(stderr, "warning: %s\n", w)
+	}
+	if len(result.Warnings) > 0 {
+		fmt.Fprintf(stderr, "%d settings could not be mapped, review the configuration and run 'herald validate' before use\n", len(result.Warnings))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"reflect"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+	"github.com/ahmet2mir/herald/pkg/metrics"
+	"github.com/ahmet2mir/herald/pkg/scheduler"
+	"github.com/ahmet2mir/herald/pkg/speaker"
+)
+
+// reloader re-reads the configuration file and applies the difference with
+// the running configuration. Reloads are serialized; a configuration that
+// fails to load, validate or apply to the neighbors leaves the running one
+// untouched.
+type reloader struct {
+	path      string
+	speaker   *speaker.Speaker
+	scheduler *scheduler.Manager
+
+	mu      sync.Mutex
+	current *config.Config
+}
+
+func newReloader(path string, c *config.Config, s *speaker.Speaker, m *scheduler.Manager) *reloader {
+	metrics.ConfigLastReloadSuccessful.Set(1)
+	metrics.ConfigLastReloadSuccessTimestamp.SetToCurrentTime()
+	return &reloader{path: path, speaker: s, scheduler: m, current: c}
+}
+
+func (r *reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	zap.S().Info("Reloading configuration", "config", r.path)
+	c, err := config.New(r.path)
+	if err != nil {
+		r.failed(err)
+		return err
+	}
+
+	for _, section := range restartOnlySections(r.current, c) {
+		zap.S().Warn("Reload: section changed, restart herald to apply it", "section", section)
+	}
+
+	// The speaker undoes its neighbor changes when one fails, prefixes then
+	// keep following the running configuration.
+	if err := r.speaker.Reload(c); err != nil {
+		r.failed(err)
+		return err
+	}
+	r.current = c
+	result := r.scheduler.Sync(c.Prefixes)
+	zap.S().Info("Reload: prefixes applied",
+		"added", result.Added, "removed", result.Removed, "changed", result.Changed, "unchanged", result.Unchanged)
+
+	metrics.ConfigReloads.WithLabelValues("success").Inc()
+	metrics.ConfigLastReloadSuccessful.Set(1)
+	metrics.ConfigLastReloadSuccessTimestamp.SetToCurrentTime()
+	zap.S().Info("Configuration reloaded")
+	return nil
+}
+
+func (r *reloader) failed(err error) {
+	zap.S().Error("Configuration reload failed: ", err)
+	metrics.ConfigReloads.WithLabelValues("failure").Inc()
+	metrics.ConfigLastReloadSuccessful.Set(0)
+}
+
+// restartOnlySections lists the sections that differ between old and c but
+// are only read at startup.
+func restartOnlySections(old, c *config.Config) []string {
+	var sections []string
+	if !reflect.DeepEqual(old.Logging, c.Logging) {
+		sections = append(sections, "logging")
+	}
+	if !reflect.DeepEqual(old.Metrics, c.Metrics) {
+		sections = append(sections, "metrics")
+	}
+	if !reflect.DeepEqual(old.BFD, c.BFD) {
+		sections = append(sections, "bfd")
+	}
+	if !reflect.DeepEqual(old.BMP, c.BMP) {
+		sections = append(sections, "bmp")
+	}
+	if !reflect.DeepEqual(old.MRT, c.MRT) {
+		sections = append(sections, "mrt")
+	}
+	if !reflect.DeepEqual(old.DynamicNeighbors, c.DynamicNeighbors) || !reflect.DeepEqual(dynamicPeerGroups(old), dynamicPeerGroups(c)) {
+		sections = append(sections, "dynamicNeighbors")
+	}
+	return sections
+}
+
+// dynamicPeerGroups returns the peer groups of the dynamic neighbors of c.
+func dynamicPeerGroups(c *config.Config) map[string]config.Neighbor {
+	groups := map[string]config.Neighbor{}
+	for _, d := range c.DynamicNeighbors {
+		groups[d.PeerGroup] = c.PeerGroups[d.PeerGroup]
+	}
+	return groups
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ahmet2mir/herald/pkg/bfd"
+	"github.com/ahmet2mir/herald/pkg/config"
+	"github.com/ahmet2mir/herald/pkg/logger"
+	"github.com/ahmet2mir/herald/pkg/metrics"
+	"github.com/ahmet2mir/herald/pkg/scheduler"
+	"github.com/ahmet2mir/herald/pkg/speaker"
+)
+
+func runCommand(args []string, stdout, stderr io.Writer, info BuildInfo) error {
+	fs := newFlagSet("run", stderr)
+	configPath := fs.String("config", DefaultConfigPath, "path to the configuration file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	c, err := config.New(*configPath)
+	if err != nil {
+		return err
+	}
+
+	cleanup, err := logger.Initialize(c.Logging)
+	if err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer cleanup()
+
+	zap.S().Info("Starting herald", "version", info.fill().Version, "config", *configPath)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	return serve(ctx, *configPath, c)
+}
+
+// serve runs the speaker, schedulers and optional subsystems until ctx is
+// cancelled. The configuration at configPath is reloaded on SIGHUP.
+func serve(ctx context.Context, configPath string, c *config.Config) error {
+	s, err := speaker.New(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	go s.Serve()
+	defer s.Stop()
+	if err := s.Start(); err != nil {
+		return err
+	}
+
+	if r := c.Speaker.Reconciliation; r != nil && r.Enabled {
+		go s.RunReconciliation(r.Interval)
+	}
+
+	schedulers := scheduler.NewManager(ctx, s)
+	defer schedulers.Stop()
+	schedulers.Sync(c.Prefixes)
+
+	r := newReloader(configPath, c, s, schedulers)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for {
+			select {
+			case <-hup:
+				_ = r.Reload()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	if c.Metrics != nil && c.Metrics.Enabled {
+		metricsServer := metrics.NewServer(c.Metrics.ListenAddress, c.Metrics.ListenPort)
+		metricsServer.SetReloadFunc(r.Reload)
+		metricsServer.SetEventsHandler(http.HandlerFunc(s.ServeEvents))
+		metricsServer.SetRoutesHandler(http.HandlerFunc(s.ServeRoutes))
+		go func() {
+			if err := metricsServer.Start(ctx); err != nil {
+				zap.S().Error("Metrics server error:", err)
+			}
+		}()
+		defer func() {
+			if err := metricsServer.Stop(); err != nil {
+				zap.S().Error("Metrics server stop error:", err)
+			}
+		}()
+
+		collector := metrics.NewGoBGPCollector(s.Server, ctx, c.Metrics.Interval)
+		collector.Start()
+		defer collector.Stop()
+	}
+
+	if c.BFD != nil && c.BFD.Enabled {
+		go func() {
+			if err := bfd.Run(c.BFD); err != nil {
+				zap.S().Error("BFD error:", err)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	zap.S().Info("Shutting down gracefully...")
+	if gs := s.Config().Speaker.GracefulShutdown; gs != nil && gs.Enabled {
+		schedulers.Stop()
+		drain(s, gs.DrainPeriod)
+	}
+	return nil
+}
+
+// drain announces every prefix with the GRACEFUL_SHUTDOWN community, waits
+// for period, or until a second signal is received, then withdraws them.
+func drain(s *speaker.Speaker, period time.Duration) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	if err := s.Drain(); err != nil {
+		zap.S().Error("Failed to drain prefixes:", err)
+	} else {
+		zap.S().Info("Waiting for neighbors to drain traffic", "drainPeriod", period)
+		t := time.NewTimer(period)
+		select {
+		case <-t.C:
+		case <-sig:
+			t.Stop()
+			zap.S().Warn("Drain interrupted")
+		}
+	}
+	if err := s.WithdrawAll(); err != nil {
+		zap.S().Error("Failed to withdraw prefixes:", err)
+	}
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ahmet2mir/herald/pkg/schema"
+)
+
+func schemaCommand(args []string, stdout, stderr io.Writer, info BuildInfo) error {
+	fs := newFlagSet("schema", stderr)
+	output := fs.String("output", "", "write the JSON Schema to this file instead of stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	data, err := schema.Generate().JSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	if *output != "" {
+		if err := os.WriteFile(*output, data, 0o644); err != nil { //nolint:gosec // The schema is not sensitive.
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+		return nil
+	}
+	_, err = stdout.Write(data)
+	return err
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+)
+
+func validateCommand(args []string, stdout, stderr io.Writer, info BuildInfo) error {
+	fs := newFlagSet("validate", stderr)
+	configPath := fs.String("config", DefaultConfigPath, "path to the configuration file")
+	quiet := fs.Bool("quiet", false, "do not print anything on success")
+	dump := fs.Bool("dump", false, "print the effective configuration, with defaults applied and secrets redacted")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	c, err := config.New(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if *dump {
+		out, err := yaml.Marshal(c)
+		if err != nil {
+			return fmt.Errorf("failed to marshal configuration: %w", err)
+		}
+		_, err = stdout.Write(out)
+		return err
+	}
+
+	if !*quiet {
+		fmt.Fprintf(stdout, "%s: configuration is valid (%d neighbors, %d prefixes)\n",
+			*configPath, len(c.Neighbors), len(c.Prefixes))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// BuildInfo describes the binary, usually injected by goreleaser through
+// -ldflags "-X main.version=...".
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+	BuiltBy string
+}
+
+// fill completes missing fields from the module build information embedded
+// by the Go toolchain, so `go build` and `go install` binaries still report
+// something useful.
+func (bi BuildInfo) fill() BuildInfo {
+	if bi.Version == "" {
+		bi.Version = "dev"
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return bi
+	}
+	if bi.Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		bi.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if bi.Commit == "" {
+				bi.Commit = s.Value
+			}
+		case "vcs.time":
+			if bi.Date == "" {
+				bi.Date = s.Value
+			}
+		}
+	}
+	return bi
+}
+
+func versionCommand(args []string, stdout, stderr io.Writer, info BuildInfo) error {
+	fs := newFlagSet("version", stderr)
+	short := fs.Bool("short", false, "print only the version number")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	bi := info.fill()
+	if *short {
+		fmt.Fprintln(stdout, bi.Version)
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "herald %s\n", bi.Version)
+	fmt.Fprintf(stdout, "  commit:     %s\n", orUnknown(bi.Commit))
+	fmt.Fprintf(stdout, "  built:      %s\n", orUnknown(bi.Date))
+	fmt.Fprintf(stdout, "  built by:   %s\n", orUnknown(bi.BuiltBy))
+	fmt.Fprintf(stdout, "  go version: %s\n", runtime.Version())
+	fmt.Fprintf(stdout, "  platform:   %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	return nil
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,162 @@
+// Package community parses BGP communities written in configuration files.
+package community
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// GracefulShutdown is the GRACEFUL_SHUTDOWN community (RFC 8326), 65535:0.
+const GracefulShutdown uint32 = 0xFFFF0000
+
+// Well-known standard communities by name (RFC 1997, RFC 3765, RFC 7611,
+// RFC 7999, RFC 8326, RFC 9494).
+var WellKnown = map[string]uint32{
+	"graceful-shutdown":   GracefulShutdown,
+	"accept-own":          0xFFFF0001,
+	"llgr-stale":          0xFFFF0006,
+	"no-llgr":             0xFFFF0007,
+	"blackhole":           0xFFFF029A,
+	"no-export":           0xFFFFFF01,
+	"no-advertise":        0xFFFFFF02,
+	"no-export-subconfed": 0xFFFFFF03,
+	"no-peer":             0xFFFFFF04,
+}
+
+// ParseASN parses an AS number in asplain ("65536") or asdot ("1.0")
+// notation (RFC 5396).
+func ParseASN(s string) (uint32, error) {
+	if high, low, ok := strings.Cut(s, "."); ok {
+		h, err := strconv.ParseUint(high, 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid asdot AS number %q", s)
+		}
+		l, err := strconv.ParseUint(low, 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("invalid asdot AS number %q", s)
+		}
+		return uint32(h<<16 | l), nil
+	}
+	asn, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid AS number %q", s)
+	}
+	return uint32(asn), nil
+}
+
+// ParseStandard parses a standard community (RFC 1997): a well-known name, a
+// 32-bit number or "ASN:value" with a 16-bit AS number and value.
+func ParseStandard(s string) (uint32, error) {
+	if v, ok := WellKnown[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	if v, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(v), nil
+	}
+	global, local, ok := strings.Cut(s, ":")
+	if !ok || strings.Contains(local, ":") {
+		return 0, fmt.Errorf("invalid community %q, expected a well-known name, N or ASN:value", s)
+	}
+	asn, err := ParseASN(global)
+	if err != nil || asn > 0xFFFF {
+		return 0, fmt.Errorf("invalid community %q, the AS number must be at most 65535", s)
+	}
+	value, err := strconv.ParseUint(local, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid community %q, the value must be at most 65535", s)
+	}
+	return asn<<16 | uint32(value), nil
+}
+
+// Large is a large community (RFC 8092).
+type Large struct {
+	GlobalAdmin uint32
+	LocalData1  uint32
+	LocalData2  uint32
+}
+
+// ParseLarge parses a large community written "ASN:function:parameter",
+// with 32-bit parts and the AS number in asplain or asdot notation.
+func ParseLarge(s string) (Large, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return Large{}, fmt.Errorf("invalid large community %q, expected ASN:function:parameter", s)
+	}
+	asn, err := ParseASN(parts[0])
+	if err != nil {
+		return Large{}, fmt.Errorf("invalid large community %q: %w", s, err)
+	}
+	var data [2]uint32
+	for i, part := range parts[1:] {
+		v, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return Large{}, fmt.Errorf("invalid large community %q, %q is not a 32-bit number", s, part)
+		}
+		data[i] = uint32(v)
+	}
+	return Large{GlobalAdmin: asn, LocalData1: data[0], LocalData2: data[1]}, nil
+}
+
+// Extended community types (RFC 4360).
+const (
+	RouteTarget = "rt"
+	RouteOrigin = "soo"
+)
+
+// extendedTypes maps the accepted type names to their canonical name.
+var extendedTypes = map[string]string{
+	"rt": RouteTarget, "route-target": RouteTarget, "target": RouteTarget,
+	"soo": RouteOrigin, "route-origin": RouteOrigin, "origin": RouteOrigin,
+}
+
+// Extended is a route target or route origin extended community. Exactly one
+// of ASN and IP is the global administrator.
+type Extended struct {
+	Type string
+	// ASN is a 2-octet AS number, or a 4-octet one when FourOctet is set.
+	ASN       uint32
+	FourOctet bool
+	IP        net.IP
+	// LocalAdmin is 32 bits wide with a 2-octet AS number, 16 bits otherwise.
+	LocalAdmin uint32
+}
+
+// ParseExtended parses an extended community written "type:admin:value",
+// where type is rt or soo and admin is an AS number (asplain or asdot) or an
+// IPv4 address. AS numbers above 65535, or written in asdot, use the 4-octet
+// AS specific format.
+func ParseExtended(s string) (*Extended, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid extended community %q, expected rt:ADMIN:VALUE or soo:ADMIN:VALUE", s)
+	}
+	t, ok := extendedTypes[strings.ToLower(parts[0])]
+	if !ok {
+		return nil, fmt.Errorf("invalid extended community %q, unknown type %q, expected rt or soo", s, parts[0])
+	}
+	e := &Extended{Type: t}
+
+	bits := 16
+	if ip := net.ParseIP(parts[1]); ip != nil && ip.To4() != nil && strings.Count(parts[1], ".") == 3 {
+		e.IP = ip.To4()
+	} else {
+		asn, err := ParseASN(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid extended community %q: %w", s, err)
+		}
+		e.ASN = asn
+		e.FourOctet = asn > 0xFFFF || strings.Contains(parts[1], ".")
+		if !e.FourOctet {
+			bits = 32
+		}
+	}
+
+	v, err := strconv.ParseUint(parts[2], 10, bits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended community %q, the value must be a %d-bit number", s, bits)
+	}
+	e.LocalAdmin = uint32(v)
+	return e, nil
+}
@@ -1,103 +1,655 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/ahmet2mir/herald/pkg/logger"
 	"github.com/ahmet2mir/herald/pkg/probe"
+	"github.com/ahmet2mir/herald/pkg/secret"
 	"github.com/ahmet2mir/herald/pkg/service"
+	"github.com/ahmet2mir/herald/pkg/validation"
 )
 
+// ConfigAPI is the GoBGP gRPC API server.
 type ConfigAPI struct {
+	// IP address the gRPC API listens on.
 	ListenAddress string `yaml:"listenAddress"`
-	ListenPort    int    `yaml:"listenPort"`
+	// TCP port the gRPC API listens on.
+	ListenPort int `yaml:"listenPort"`
 }
 
 func (ca *ConfigAPI) GetURI() string {
 	return fmt.Sprintf("%s:%d", ca.ListenAddress, ca.ListenPort)
 }
 
+// MetricsConfig is the Prometheus metrics endpoint.
 type MetricsConfig struct {
-	Enabled       bool          `yaml:"enabled"`
-	ListenAddress string        `yaml:"listenAddress"`
-	ListenPort    int           `yaml:"listenPort"`
-	Interval      time.Duration `yaml:"interval"`
+	// Serve Prometheus metrics on /metrics.
+	Enabled bool `yaml:"enabled"`
+	// IP address the metrics server listens on. Defaults to 127.0.0.1.
+	ListenAddress string `yaml:"listenAddress"`
+	// TCP port the metrics server listens on. Defaults to 9091.
+	ListenPort int `yaml:"listenPort"`
+	// How often BGP metrics are collected from GoBGP. Defaults to 15s.
+	Interval time.Duration `yaml:"interval"`
 }
 
 func (mc *MetricsConfig) GetURI() string {
 	return fmt.Sprintf("%s:%d", mc.ListenAddress, mc.ListenPort)
 }
 
+// Config is the herald configuration file.
 type Config struct {
-	Logging   logger.Config  `yaml:"logging"`
-	Metrics   *MetricsConfig `yaml:"metrics"`
-	Speaker   Speaker        `yaml:"speaker"`
-	BFD       *BFDConfig     `yaml:"bfd"`
-	API       ConfigAPI      `yaml:"api"`
-	Neighbors []Neighbor     `yaml:"neighbors"`
-	Prefixes  []Prefix       `yaml:"prefixes"`
+	// Glob patterns of drop-in files contributing neighbors and prefixes,
+	// relative to the directory of this file (e.g. "conf.d/*.yaml").
+	Include []string `yaml:"include"`
+
+	Logging logger.Config  `yaml:"logging"`
+	Metrics *MetricsConfig `yaml:"metrics"`
+	Speaker Speaker        `yaml:"speaker"`
+	BFD     *BFDConfig     `yaml:"bfd"`
+	BMP     *BMPConfig     `yaml:"bmp"`
+	MRT     *MRTConfig     `yaml:"mrt"`
+	API     ConfigAPI      `yaml:"api"`
+	// BGP peers every prefix is announced to.
+	Neighbors []Neighbor `yaml:"neighbors"`
+	// Ranges herald accepts sessions from, with the settings of a peer
+	// group. Requires speaker.listenPort.
+	DynamicNeighbors []DynamicNeighbor `yaml:"dynamicNeighbors"`
+	// Prefixes announced while their readiness probe succeeds.
+	Prefixes []Prefix `yaml:"prefixes"`
+
+	// Named probes that prefixes reference with "check: <name>". Prefixes
+	// using the same unmodified check share a single execution per period.
+	Checks map[string]*probe.Probe `yaml:"checks"`
+	// Named partial prefixes that prefixes reference with
+	// "template: <name>" and override field by field.
+	PrefixTemplates map[string]Prefix `yaml:"prefixTemplates"`
+	// Named partial neighbors that neighbors reference with
+	// "peerGroup: <name>" and override field by field. Dynamic neighbors
+	// take all their settings from their peer group.
+	PeerGroups map[string]Neighbor `yaml:"peerGroups"`
+
+	sources *sources
 }
 
+// Speaker is the local BGP speaker.
 type Speaker struct {
-	ASN                        uint32 `yaml:"asn"`
-	RouterID                   string `yaml:"routerId"`
-	GracefulRestartEnabled     bool   `yaml:"gracefulRestartEnabled"`
+	// Local autonomous system number.
+	ASN uint32 `yaml:"asn"`
+	// BGP router ID, an IPv4 address.
+	RouterID string `yaml:"routerId"`
+	// Advertise the graceful restart capability (RFC 4724).
+	GracefulRestartEnabled bool `yaml:"gracefulRestartEnabled"`
+	// Restart time advertised to neighbors, in seconds (at most 4095).
 	GracefulRestartRestartTime uint32 `yaml:"gracefulRestartRestartTime"`
+	// TCP port accepting BGP connections, needed by passive neighbors.
+	// Herald does not listen when unset.
+	ListenPort int32 `yaml:"listenPort"`
+	// Addresses accepting BGP connections when listenPort is set. Defaults to
+	// all addresses.
+	ListenAddresses []string `yaml:"listenAddresses"`
+	// Drain traffic before withdrawing prefixes on shutdown and maintenance.
+	GracefulShutdown *GracefulShutdown `yaml:"gracefulShutdown"`
+	// Check that announced prefixes reached the neighbors.
+	Reconciliation *Reconciliation `yaml:"reconciliation"`
+}
+
+// Reconciliation periodically compares the Adj-RIB-Out of each neighbor
+// with the prefixes herald announced and announces missing ones again.
+type Reconciliation struct {
+	// Run the reconciliation.
+	Enabled bool `yaml:"enabled"`
+	// Interval between reconciliations. Defaults to 1m.
+	Interval time.Duration `yaml:"interval"`
 }
 
+// GracefulShutdown drains traffic away from prefixes before withdrawing them
+// by announcing them with the GRACEFUL_SHUTDOWN community (RFC 8326).
+type GracefulShutdown struct {
+	// Drain prefixes on SIGTERM and when their maintenance file appears.
+	Enabled bool `yaml:"enabled"`
+	// Time left to neighbors to move traffic elsewhere before prefixes are
+	// withdrawn. Defaults to 30s.
+	DrainPeriod time.Duration `yaml:"drainPeriod"`
+	// LOCAL_PREF attribute sent to iBGP neighbors while draining. Defaults
+	// to 0.
+	LocalPreference uint32 `yaml:"localPreference"`
+}
+
+// BFDConfig is the BFD agent detecting neighbor failures (RFC 5880).
 type BFDConfig struct {
-	Enabled                     bool          `yaml:"enabled"`
-	ListenAddress               string        `yaml:"listenAddress"`
-	ListenPort                  int           `yaml:"listenPort"`
-	MinimumReceptionInterval    time.Duration `yaml:"minimumReceptionInterval"`
+	// Run BFD sessions with the neighbors.
+	Enabled bool `yaml:"enabled"`
+	// IP address BFD listens on. Defaults to 0.0.0.0.
+	ListenAddress string `yaml:"listenAddress"`
+	// UDP port BFD listens on. Defaults to 3784.
+	ListenPort int `yaml:"listenPort"`
+	// Minimum interval between received BFD control packets. Defaults to 1s.
+	MinimumReceptionInterval time.Duration `yaml:"minimumReceptionInterval"`
+	// Minimum interval between transmitted BFD control packets. Defaults to 1s.
 	MinimumTransmissionInterval time.Duration `yaml:"minimumTransmissionInterval"`
-	DetectionMultiplier         uint8         `yaml:"detectionMultiplier"`
-	Passive                     bool          `yaml:"passive"`
+	// Number of missed packets before a session is declared down. Defaults to 3.
+	DetectionMultiplier uint8 `yaml:"detectionMultiplier"`
+	// Wait for the neighbor to start the BFD session.
+	Passive bool `yaml:"passive"`
 }
 
 func (bc *BFDConfig) GetListenURI() string {
 	return fmt.Sprintf("%s:%d", bc.ListenAddress, bc.ListenPort)
 }
 
+// Neighbor is a BGP peer.
 type Neighbor struct {
-	Address             string `yaml:"address"`
-	ASN                 uint32 `yaml:"asn"`
-	EbgpMultihopEnabled bool   `yaml:"ebgpMultihopEnabled"`
+	// Name of the peer group this neighbor belongs to and inherits from.
+	PeerGroup string `yaml:"peerGroup"`
+
+	// IP address of the peer.
+	Address string `yaml:"address"`
+	// Autonomous system number of the peer.
+	ASN uint32 `yaml:"asn"`
+	// Allow eBGP sessions with peers that are not directly connected.
+	EbgpMultihopEnabled bool `yaml:"ebgpMultihopEnabled"`
+	// TTL of packets sent to a multihop peer. Defaults to 255.
+	EbgpMultihopTTL uint8 `yaml:"ebgpMultihopTtl"`
+	// Drop packets from the peer received with a TTL below ttlMin (GTSM,
+	// RFC 5082).
+	TTLSecurityEnabled bool `yaml:"ttlSecurityEnabled"`
+	// Minimum TTL accepted when ttlSecurityEnabled is set. Defaults to 255,
+	// for directly connected peers.
+	TTLMin uint8 `yaml:"ttlMin"`
+	// Source address of the BGP session. Chosen by the kernel when unset.
+	LocalAddress string `yaml:"localAddress"`
+	// Wait for the peer to connect instead of connecting to it. Requires
+	// speaker.listenPort.
+	Passive bool `yaml:"passive"`
+	// Hold time proposed to the peer, at least 3s. Defaults to 90s.
+	HoldTime time.Duration `yaml:"holdTime"`
+	// Interval between keepalive messages. Defaults to a third of holdTime,
+	// rounded down to whole seconds.
+	KeepaliveInterval time.Duration `yaml:"keepaliveInterval"`
+	// Interval between connection attempts. Defaults to 120s.
+	ConnectRetry time.Duration `yaml:"connectRetry"`
+	// Address families negotiated with the peer: ipv4-unicast and
+	// ipv6-unicast. Defaults to the family of address, or of the first
+	// range of a peer group.
+	Families []string `yaml:"families"`
+
+	// TCP MD5 authentication password (RFC 2385), inline or read from a file
+	// with valueFrom.
+	Password secret.Value `yaml:"password"`
 }
 
+// DynamicNeighbor is a range of addresses herald accepts BGP sessions from.
+type DynamicNeighbor struct {
+	// Range in CIDR notation (e.g. 198.51.100.0/24).
+	Prefix string `yaml:"prefix"`
+	// Peer group whose settings apply to the sessions from the range.
+	PeerGroup string `yaml:"peerGroup"`
+}
+
+// Dynamic returns the settings of the session with address accepted from a
+// dynamic neighbor range, those of the peer group of the first range
+// containing it. Configured neighbors are not dynamic.
+func (c *Config) Dynamic(address string) (Neighbor, bool) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return Neighbor{}, false
+	}
+	for _, n := range c.Neighbors {
+		if ip.Equal(net.ParseIP(n.Address)) {
+			return Neighbor{}, false
+		}
+	}
+	for _, d := range c.DynamicNeighbors {
+		if _, nw, err := net.ParseCIDR(d.Prefix); err == nil && nw.Contains(ip) {
+			n := c.PeerGroups[d.PeerGroup]
+			n.PeerGroup, n.Address = d.PeerGroup, ip.String()
+			return n, true
+		}
+	}
+	return Neighbor{}, false
+}
+
+// Selects reports whether selector, addresses of neighbors and names of peer
+// groups, selects the neighbor at address. An empty selector selects every
+// neighbor.
+func (c *Config) Selects(selector []string, address string) bool {
+	if len(selector) == 0 {
+		return true
+	}
+	ip := net.ParseIP(address)
+	group := ""
+	for _, n := range c.Neighbors {
+		if ip.Equal(net.ParseIP(n.Address)) {
+			group = n.PeerGroup
+		}
+	}
+	if n, ok := c.Dynamic(address); ok {
+		group = n.PeerGroup
+	}
+	for _, s := range selector {
+		if (group != "" && s == group) || ip.Equal(net.ParseIP(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ranges returns the networks of the neighbors selected by selector: a host
+// route for each neighbor address and for each configured member of a peer
+// group, and the dynamic neighbor ranges of the peer group.
+func (c *Config) Ranges(selector []string) []string {
+	var ranges []string
+	add := func(address string) {
+		ip := net.ParseIP(address)
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			bits = 8 * net.IPv4len
+		}
+		ranges = append(ranges, fmt.Sprintf("%s/%d", ip, bits))
+	}
+	for _, s := range selector {
+		if net.ParseIP(s) != nil {
+			add(s)
+			continue
+		}
+		for _, n := range c.Neighbors {
+			if n.PeerGroup == s {
+				add(n.Address)
+			}
+		}
+		for _, d := range c.DynamicNeighbors {
+			if _, nw, err := net.ParseCIDR(d.Prefix); err == nil && d.PeerGroup == s {
+				ranges = append(ranges, nw.String())
+			}
+		}
+	}
+	return ranges
+}
+
+// Prefix is an announced prefix and the health checks controlling it.
 type Prefix struct {
-	IPAddress              string   `yaml:"ipAddress"`
-	Name                   string   `yaml:"name"`
-	Communities            []string `yaml:"communities"`
-	NextHop                string   `yaml:"nextHop"`
-	ASN                    uint32   `yaml:"asn"`
-	MultiExitDescriminator uint32   `yaml:"multiExitDescriminator"`
-	AsPathPrepend          []uint32 `yaml:"asPathPrepend"`
-	WithdrawOnDown         bool     `yaml:"withdrawOnDown"`
-	Maintenance            string   `yaml:"maintenance"`
+	// Name of the prefix template this prefix is based on.
+	Template string `yaml:"template"`
 
+	// Announced prefix in CIDR notation (e.g. 192.0.2.1/32).
+	IPAddress string `yaml:"ipAddress"`
+	// Name used in logs and metrics. Defaults to ipAddress.
+	Name string `yaml:"name"`
+	// Standard communities attached to the route, as ASN:value, a 32-bit
+	// number or a well-known name such as no-export or graceful-shutdown.
+	Communities []string `yaml:"communities"`
+	// Large communities attached to the route, as ASN:function:parameter.
+	LargeCommunities []string `yaml:"largeCommunities"`
+	// Extended communities attached to the route, as rt:ADMIN:VALUE or
+	// soo:ADMIN:VALUE where ADMIN is an AS number or an IPv4 address.
+	ExtendedCommunities []string `yaml:"extendedCommunities"`
+	// Local interface ipAddress is added to before the prefix is announced,
+	// e.g. lo. The prefix is not announced when the address cannot be
+	// added. Linux only.
+	Interface string `yaml:"interface"`
+	// Keep ipAddress on interface after the prefix is withdrawn and when
+	// herald stops.
+	KeepAddress bool `yaml:"keepAddress"`
+	// Next hop of the route, in the address family of ipAddress. Defaults to
+	// the primary address of nextHopInterface, or else to the local address
+	// of the BGP session with each neighbor (next-hop-self).
+	NextHop string `yaml:"nextHop"`
+	// Interface whose primary address in the address family of ipAddress is
+	// the next hop, resolved at each announcement.
+	NextHopInterface string `yaml:"nextHopInterface"`
+	// Optional IPv6 link-local next hop sent along with nextHop for IPv6
+	// prefixes.
+	NextHopLinkLocal string `yaml:"nextHopLinkLocal"`
+	// AS number the route appears to originate from, last in the AS path.
+	// Ignored when equal to speaker.asn.
+	ASN uint32 `yaml:"asn"`
+	// ORIGIN attribute of the route: igp, egp or incomplete. Defaults to igp.
+	Origin string `yaml:"origin"`
+	// MULTI_EXIT_DISC attribute of the route, not sent when 0.
+	MultiExitDescriminator uint32 `yaml:"multiExitDescriminator"`
+	// LOCAL_PREF attribute sent to iBGP neighbors. GoBGP sends 100 when 0.
+	LocalPreference uint32 `yaml:"localPreference"`
+	// AS numbers prepended to the AS path, before asn. The speaker AS number
+	// is added in front of them for eBGP neighbors.
+	AsPathPrepend []uint32 `yaml:"asPathPrepend"`
+	// Addresses of the neighbors and names of the peer groups the prefix is
+	// announced to. Defaults to every neighbor.
+	Neighbors []string `yaml:"neighbors"`
+	// Attributes changed for the routes sent to some neighbors.
+	NeighborOverrides []NeighborOverride `yaml:"neighborOverrides"`
+	// Withdraw the route when the readiness probe fails failureThreshold
+	// times in a row, instead of announcing it degraded. Defaults to true
+	// without degraded and to false with it.
+	WithdrawOnDown *bool `yaml:"withdrawOnDown"`
+	// Number of established sessions with the neighbors of the prefix below
+	// which probe results are not acted upon, leaving the prefix as it is.
+	MinEstablishedPeers int `yaml:"minEstablishedPeers"`
+	// Routes that must be received for the prefix to be announced, e.g. a
+	// default route from the upstream routers.
+	RouteCondition *RouteCondition `yaml:"routeCondition"`
+	// Path of a file whose presence, checked at each readiness probe, puts
+	// the prefix in maintenance: it is drained when
+	// speaker.gracefulShutdown is enabled, then withdrawn.
+	Maintenance string `yaml:"maintenance"`
+
+	// Service checked before probing and restarted by the liveness probe.
 	Service *service.Service `yaml:"service"`
 
-	LivenessProbe  *probe.Probe `yaml:"livenessProbe"`
-	StartupProbe   *probe.Probe `yaml:"startupProbe"`
+	// Probe restarting the service when it fails.
+	LivenessProbe *probe.Probe `yaml:"livenessProbe"`
+	// Probe run once before the others start.
+	StartupProbe *probe.Probe `yaml:"startupProbe"`
+	// Probe announcing the prefix on success and withdrawing it on failure.
 	ReadinessProbe *probe.Probe `yaml:"readinessProbe"`
+	// Probe announcing the prefix with the degraded attributes on failure.
+	// Requires degraded.
+	DegradedProbe *probe.Probe `yaml:"degradedProbe"`
+	// Attributes the prefix is announced with while degraded, i.e. when the
+	// degraded probe fails, or when the readiness probe fails without
+	// withdrawOnDown and fewer times than the withdraw threshold.
+	Degraded *Degraded `yaml:"degraded"`
+	// Probe whose output is the bandwidth announced in linkBandwidth, e.g.
+	// the free capacity of the service. Requires linkBandwidth.
+	BandwidthProbe *probe.Probe `yaml:"bandwidthProbe"`
+	// Link bandwidth extended community announced with the prefix so that
+	// routers balance traffic between nodes by weight.
+	LinkBandwidth *LinkBandwidth `yaml:"linkBandwidth"`
+}
+
+// NeighborOverride changes the attributes of a prefix sent to some
+// neighbors. The first override listing a neighbor applies.
+type NeighborOverride struct {
+	// Addresses of the neighbors and names of the peer groups the override
+	// applies to.
+	Neighbors []string `yaml:"neighbors"`
+	// MULTI_EXIT_DISC attribute replacing the prefix one.
+	MultiExitDescriminator uint32 `yaml:"multiExitDescriminator"`
+	// AS number prepended to the AS path, repeated as many times as listed.
+	AsPathPrepend []uint32 `yaml:"asPathPrepend"`
+	// Standard communities added to the prefix ones.
+	Communities []string `yaml:"communities"`
+	// Large communities added to the prefix ones.
+	LargeCommunities []string `yaml:"largeCommunities"`
+}
+
+// RouteCondition withdraws a prefix while none of some routes is received,
+// so that a node that lost its upstream connectivity stops attracting
+// traffic.
+type RouteCondition struct {
+	// Prefixes of the routes, in CIDR notation. At least one must be
+	// received.
+	Prefixes []string `yaml:"prefixes"`
+	// Addresses of the neighbors and names of the peer groups the routes
+	// must be received from. Defaults to every neighbor.
+	Neighbors []string `yaml:"neighbors"`
+}
+
+// Degraded holds the attributes of a prefix announced in degraded mode, to
+// keep attracting some traffic instead of withdrawing it.
+type Degraded struct {
+	// MULTI_EXIT_DISC attribute replacing the prefix one while degraded.
+	MultiExitDescriminator uint32 `yaml:"multiExitDescriminator"`
+	// AS numbers prepended in front of the prefix asPathPrepend while
+	// degraded.
+	AsPathPrepend []uint32 `yaml:"asPathPrepend"`
+	// Standard communities added to the prefix ones while degraded.
+	Communities []string `yaml:"communities"`
+	// Large communities added to the prefix ones while degraded.
+	LargeCommunities []string `yaml:"largeCommunities"`
+	// Consecutive readiness probe failures after which the prefix is
+	// withdrawn. Below it, the prefix stays announced degraded. 0 never
+	// withdraws it. Requires withdrawOnDown false.
+	WithdrawThreshold int32 `yaml:"withdrawThreshold"`
+}
+
+// LinkBandwidth is the link bandwidth extended community of a prefix
+// (draft-ietf-idr-link-bandwidth), used by routers for weighted ECMP.
+type LinkBandwidth struct {
+	// Bandwidth in bits per second, with an optional k, M, G or T suffix.
+	// Announced until the bandwidth probe first succeeds.
+	Bandwidth string `yaml:"bandwidth"`
+	// Change of the probed bandwidth, in percent of the announced one, below
+	// which the prefix is not announced again. Defaults to 0, announcing
+	// every change.
+	Hysteresis uint32 `yaml:"hysteresis"`
+	// AS number of the community. Defaults to speaker.asn, or 23456
+	// (AS_TRANS) when it does not fit in 16 bits.
+	ASN uint32 `yaml:"asn"`
+}
+
+// ParseBandwidth parses a bandwidth in bits per second with an optional k,
+// M, G or T suffix, surrounding spaces ignored.
+func ParseBandwidth(s string) (float64, error) {
+	value := strings.TrimSpace(s)
+	multiplier := 1.0
+	if n := len(value); n > 0 {
+		switch value[n-1] {
+		case 'k', 'K':
+			multiplier = 1e3
+		case 'M':
+			multiplier = 1e6
+		case 'G':
+			multiplier = 1e9
+		case 'T':
+			multiplier = 1e12
+		}
+		if multiplier != 1 {
+			value = value[:n-1]
+		}
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("must be a number of bits per second with an optional k, M, G or T suffix, got %q", s)
+	}
+	return v * multiplier, nil
+}
+
+// WithBandwidth returns p announcing a link bandwidth of bps bits per second.
+func (p Prefix) WithBandwidth(bps float64) Prefix {
+	if p.LinkBandwidth == nil {
+		return p
+	}
+	lb := *p.LinkBandwidth
+	lb.Bandwidth = strconv.FormatFloat(bps, 'f', -1, 64)
+	p.LinkBandwidth = &lb
+	return p
+}
+
+// BMPConfig exports the BGP sessions and routes of the speaker to a BMP
+// collector (RFC 7854).
+type BMPConfig struct {
+	// Connect to the collector.
+	Enabled bool `yaml:"enabled"`
+	// IP address or host name of the collector.
+	Address string `yaml:"address"`
+	// TCP port of the collector. Defaults to 11019.
+	Port int `yaml:"port"`
+	// Routes reported: pre-policy, post-policy or both for the routes
+	// received from neighbors, local-rib for the best routes, including the
+	// prefixes announced by herald (RFC 9069), or all. Defaults to
+	// local-rib.
+	RouteMonitoringPolicy string `yaml:"routeMonitoringPolicy"`
+	// Interval between statistics reports, in whole seconds. 0 disables
+	// them.
+	StatisticsInterval time.Duration `yaml:"statisticsInterval"`
+	// Name the speaker reports to the collector. Defaults to the host name.
+	SysName string `yaml:"sysName"`
+}
+
+// Route monitoring policies of BMPConfig.
+const (
+	BMPPrePolicy  = "pre-policy"
+	BMPPostPolicy = "post-policy"
+	BMPBoth       = "both"
+	BMPLocalRIB   = "local-rib"
+	BMPAll        = "all"
+)
+
+// MRTConfig dumps BGP messages and routing tables to MRT files (RFC 6396)
+// for later analysis.
+type MRTConfig struct {
+	// Write the dumps.
+	Enabled bool `yaml:"enabled"`
+	// UPDATE messages received from neighbors.
+	Updates *MRTDump `yaml:"updates"`
+	// Snapshots of the routing table, including the prefixes announced by
+	// herald, taken at each rotation.
+	Table *MRTDump `yaml:"table"`
+}
+
+// MRTDump is a series of MRT files.
+type MRTDump struct {
+	// Path of the files, a Go time layout expanded when a file is created,
+	// e.g. /var/lib/herald/mrt/updates.20060102.1504.mrt.
+	FileName string `yaml:"fileName"`
+	// Interval between files, in whole seconds, at least 1m. Defaults to 1h.
+	RotationInterval time.Duration `yaml:"rotationInterval"`
+}
+
+// Address families of neighbors and prefixes.
+const (
+	FamilyIPv4Unicast = "ipv4-unicast"
+	FamilyIPv6Unicast = "ipv6-unicast"
+)
+
+// addressFamily returns the unicast family of ip.
+func addressFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return FamilyIPv4Unicast
+	}
+	return FamilyIPv6Unicast
 }
 
+// Family returns the address family of the prefix, or "" when ipAddress is
+// not a valid prefix.
+func (p *Prefix) Family() string {
+	ip, _, err := net.ParseCIDR(p.IPAddress)
+	if err != nil {
+		return ""
+	}
+	return addressFamily(ip)
+}
+
+// ASPath returns the AS numbers of the AS_PATH announced with the prefix by
+// a speaker with AS number speakerASN, before it prepends its own for eBGP
+// neighbors. An asn equal to speakerASN means the route originates locally.
+func (p *Prefix) ASPath(speakerASN uint32) []uint32 {
+	asPath := append([]uint32{}, p.AsPathPrepend...)
+	if p.ASN != 0 && p.ASN != speakerASN {
+		asPath = append(asPath, p.ASN)
+	}
+	return asPath
+}
+
+// DegradedPrefix returns the prefix with its degraded attributes applied, or
+// the prefix itself when it has none.
+func (p Prefix) DegradedPrefix() Prefix {
+	d := p.Degraded
+	if d == nil {
+		return p
+	}
+	if d.MultiExitDescriminator != 0 {
+		p.MultiExitDescriminator = d.MultiExitDescriminator
+	}
+	p.AsPathPrepend = append(append([]uint32{}, d.AsPathPrepend...), p.AsPathPrepend...)
+	p.Communities = append(append([]string{}, p.Communities...), d.Communities...)
+	p.LargeCommunities = append(append([]string{}, p.LargeCommunities...), d.LargeCommunities...)
+	return p
+}
+
+// New reads, strictly decodes and validates the configuration file at
+// configPath, merging the neighbors and prefixes of the files matched by its
+// include patterns. Unknown fields are rejected and every validation error is
+// reported at once with the file, line and column it comes from.
 func New(configPath string) (*Config, error) {
 	c := &Config{}
-	configBytes, err := os.ReadFile(filepath.Clean(configPath))
+	root, defs, err := decodeFile(configPath, c, nil)
 	if err != nil {
-		return nil, fmt.Errorf("NewConfigFromFile error when reading file %s: %w", configPath, err)
+		return nil, err
 	}
 
-	if err := yaml.Unmarshal(configBytes, &c); err != nil {
-		return nil, fmt.Errorf("NewConfigFromFile error unmarshal file %s: %w", configPath, err)
+	src := newSources(configPath, root, c)
+	if err := c.loadIncludes(configPath, src, defs); err != nil {
+		return nil, err
+	}
+	c.sources = src
+
+	if err := c.Validate(); err != nil {
+		var errs validation.Errors
+		if errors.As(err, &errs) {
+			src.locate(errs)
+			err = errs.Unique()
+		}
+		return nil, fmt.Errorf("invalid configuration %s:\n%w", configPath, err)
 	}
 
 	return c, nil
 }
+
+// decodeFile reads path and decodes it into out, rejecting unknown fields.
+// Checks and prefix templates are taken from defs, or from the file itself
+// when defs is nil. It also returns the YAML node tree used to locate
+// validation errors and the definitions used.
+func decodeFile(path string, out any, defs *definitions) (*yaml.Node, *definitions, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("NewConfigFromFile error when reading file %s: %w", path, err)
+	}
+
+	root, defs, err := decode(data, out, defs)
+	if err != nil {
+		var typeErr *yaml.TypeError
+		var errs validation.Errors
+		switch {
+		case errors.As(err, &typeErr):
+			err = decodeErrors(path, typeErr)
+		case errors.As(err, &errs):
+			for _, e := range errs {
+				e.File = path
+			}
+		}
+		return nil, nil, fmt.Errorf("NewConfigFromFile error unmarshal file %s:\n%w", path, err)
+	}
+	return root, defs, nil
+}
+
+// decode interpolates environment variables in data, applies checks and
+// prefix templates and decodes it into out, rejecting unknown fields.
+func decode(data []byte, out any, defs *definitions) (*yaml.Node, *definitions, error) {
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal(data, root); err != nil {
+		return nil, nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, nil, fmt.Errorf("configuration is empty")
+	}
+
+	var errs validation.Errors
+	interpolate(root, &errs)
+	if defs == nil {
+		defs = collectDefinitions(root)
+	}
+	applyDefinitions(root, defs, &errs)
+	checkKnownFields(root, reflect.TypeOf(out), &errs)
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	if err := root.Decode(out); err != nil {
+		return nil, nil, err
+	}
+	return root, defs, nil
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ahmet2mir/herald/pkg/validation"
+)
+
+// Fragment is a drop-in file matched by Config.Include. It can only
+// contribute neighbors and prefixes.
+type Fragment struct {
+	Neighbors []Neighbor `yaml:"neighbors"`
+	Prefixes  []Prefix   `yaml:"prefixes"`
+}
+
+// loadIncludes appends the neighbors and prefixes of every file matched by
+// c.Include, in lexical order, and records where they come from in src.
+func (c *Config) loadIncludes(configPath string, src *sources, defs *definitions) error {
+	var errs []error
+	for _, file := range c.includedFiles(configPath, &errs) {
+		f := &Fragment{}
+		root, _, err := decodeFile(file, f, defs)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for i := range f.Neighbors {
+			src.neighbors = append(src.neighbors, entrySource{file: file, root: root, index: i})
+		}
+		for i := range f.Prefixes {
+			src.prefixes = append(src.prefixes, entrySource{file: file, root: root, index: i})
+		}
+		c.Neighbors = append(c.Neighbors, f.Neighbors...)
+		c.Prefixes = append(c.Prefixes, f.Prefixes...)
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) includedFiles(configPath string, errs *[]error) []string {
+	dir := filepath.Dir(configPath)
+	seen := map[string]bool{}
+	var files []string
+	for i, pattern := range c.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: include[%d]: invalid pattern %q: %w", configPath, i, c.Include[i], err))
+			continue
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			if seen[m] || filepath.Clean(m) == filepath.Clean(configPath) {
+				continue
+			}
+			seen[m] = true
+			files = append(files, m)
+		}
+	}
+	return files
+}
+
+// sources records the file each neighbor and prefix was defined in, so
+// validation errors can be reported against the right file and line.
+type sources struct {
+	file      string
+	root      *yaml.Node
+	neighbors []entrySource
+	prefixes  []entrySource
+}
+
+type entrySource struct {
+	file  string
+	root  *yaml.Node
+	index int
+}
+
+func newSources(file string, root *yaml.Node, c *Config) *sources {
+	src := &sources{file: file, root: root}
+	for i := range c.Neighbors {
+		src.neighbors = append(src.neighbors, entrySource{file: file, root: root, index: i})
+	}
+	for i := range c.Prefixes {
+		src.prefixes = append(src.prefixes, entrySource{file: file, root: root, index: i})
+	}
+	return src
+}
+
+// resolve maps a path of the merged configuration to the file, node tree
+// and path it was defined at.
+func (src *sources) resolve(path string) (string, *yaml.Node, string) {
+	segments := splitPath(path)
+	if len(segments) < 2 || segments[1].index < 0 {
+		return src.file, src.root, path
+	}
+
+	var entries []entrySource
+	switch segments[0].key {
+	case "neighbors":
+		entries = src.neighbors
+	case "prefixes":
+		entries = src.prefixes
+	}
+	i := segments[1].index
+	if i >= len(entries) {
+		return src.file, src.root, path
+	}
+
+	e := entries[i]
+	prefix := validation.Index(segments[0].key, i)
+	return e.file, e.root, validation.Index(segments[0].key, e.index) + path[len(prefix):]
+}
+
+// locate sets the file, line and column of every error and rewrites its
+// path relative to the file it was defined in.
+func (src *sources) locate(errs validation.Errors) {
+	for _, e := range errs {
+		file, root, path := src.resolve(e.Path)
+		e.File, e.Path = file, path
+		if n := lookup(root, path); n != nil {
+			e.Line, e.Column = n.Line, n.Column
+		}
+	}
+}
+
+// describe returns where the entry at path was defined, for messages
+// referring to another entry.
+func (c *Config) describe(path string) string {
+	if c.sources == nil {
+		return path
+	}
+	file, root, local := c.sources.resolve(path)
+	if n := lookup(root, local); n != nil {
+		return fmt.Sprintf("%s:%d", file, n.Line)
+	}
+	return file
+}
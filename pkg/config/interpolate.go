@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ahmet2mir/herald/pkg/validation"
+)
+
+// interpolate replaces ${VAR} and ${VAR:-default} in every scalar value of
+// the document with the environment. "$$" is a literal "$". Keys are left
+// as is. Plain scalars get their tag resolved again so numbers and booleans
+// can come from the environment too.
+func interpolate(n *yaml.Node, errs *validation.Errors) {
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			interpolate(c, errs)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			interpolate(n.Content[i], errs)
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(n.Value, "$") {
+			return
+		}
+		value, err := expand(n.Value)
+		if err != nil {
+			*errs = append(*errs, &validation.Error{Line: n.Line, Column: n.Column, Err: err})
+			return
+		}
+		if value != n.Value {
+			n.Value = value
+			if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+				n.Tag = ""
+			}
+		}
+	}
+}
+
+func expand(s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			s = s[i+2:]
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference in %q", s[i:])
+			}
+			ref := s[i+2 : i+end]
+			name, def, hasDefault := strings.Cut(ref, ":-")
+			if name == "" {
+				return "", fmt.Errorf("empty variable reference")
+			}
+			value, ok := os.LookupEnv(name)
+			switch {
+			case ok && value != "":
+				b.WriteString(value)
+			case hasDefault:
+				b.WriteString(def)
+			case ok:
+			default:
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			s = s[i+end+1:]
+		default:
+			b.WriteByte('$')
+			s = s[i+1:]
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ahmet2mir/herald/pkg/validation"
+)
+
+// lookup walks path ("prefixes[0].readinessProbe.http.port") from root and
+// returns the deepest node found. Keys are returned rather than values so
+// the position points at the offending field name.
+func lookup(root *yaml.Node, path string) *yaml.Node {
+	n := root
+	if n != nil && n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	if n == nil || path == "" {
+		return n
+	}
+
+	found := n
+	for _, segment := range splitPath(path) {
+		switch {
+		case segment.index >= 0:
+			if n.Kind != yaml.SequenceNode || segment.index >= len(n.Content) {
+				return found
+			}
+			n = n.Content[segment.index]
+			found = n
+		default:
+			if n.Kind != yaml.MappingNode {
+				return found
+			}
+			next := -1
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == segment.key {
+					next = i
+					break
+				}
+			}
+			if next < 0 {
+				return found
+			}
+			found = n.Content[next]
+			n = n.Content[next+1]
+		}
+	}
+	return found
+}
+
+type pathSegment struct {
+	key   string
+	index int
+}
+
+func splitPath(path string) []pathSegment {
+	var segments []pathSegment
+	for _, field := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(field, "[")
+		if key != "" {
+			segments = append(segments, pathSegment{key: key, index: -1})
+		}
+		for rest != "" {
+			idx, after, _ := strings.Cut(rest, "]")
+			i, err := strconv.Atoi(idx)
+			if err != nil {
+				break
+			}
+			segments = append(segments, pathSegment{index: i})
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return segments
+}
+
+var regexpDecodeError = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// decodeErrors converts the messages of a yaml.TypeError, such as unknown
+// fields, into validation errors carrying the file and line.
+func decodeErrors(file string, typeErr *yaml.TypeError) validation.Errors {
+	errs := make(validation.Errors, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		e := &validation.Error{File: file, Err: errors.New(msg)}
+		if m := regexpDecodeError.FindStringSubmatch(msg); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Err = errors.New(m[2])
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
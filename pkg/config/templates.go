@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ahmet2mir/herald/pkg/validation"
+)
+
+// definitions holds the YAML nodes of the named checks, prefix templates and
+// peer groups declared in the main configuration file.
+type definitions struct {
+	checks     map[string]*yaml.Node
+	templates  map[string]*yaml.Node
+	peerGroups map[string]*yaml.Node
+}
+
+var probeKeys = []string{"startupProbe", "livenessProbe", "readinessProbe", "degradedProbe", "bandwidthProbe"}
+
+// collectDefinitions returns the checks, prefixTemplates and peerGroups of a
+// document.
+func collectDefinitions(root *yaml.Node) *definitions {
+	defs := &definitions{
+		checks:     map[string]*yaml.Node{},
+		templates:  map[string]*yaml.Node{},
+		peerGroups: map[string]*yaml.Node{},
+	}
+	doc := documentMapping(root)
+	if n := mappingValue(doc, "checks"); n != nil && n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			defs.checks[n.Content[i].Value] = n.Content[i+1]
+		}
+	}
+	if n := mappingValue(doc, "prefixTemplates"); n != nil && n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			defs.templates[n.Content[i].Value] = n.Content[i+1]
+		}
+	}
+	if n := mappingValue(doc, "peerGroups"); n != nil && n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			defs.peerGroups[n.Content[i].Value] = n.Content[i+1]
+		}
+	}
+	return defs
+}
+
+// applyDefinitions replaces, in place, every neighbor referencing a peer
+// group, every prefix referencing a template and every probe referencing a
+// check with the definition overridden field by field by the reference.
+// Lists and scalars of the reference replace those of the definition,
+// mappings are merged recursively.
+func applyDefinitions(root *yaml.Node, defs *definitions, errs *validation.Errors) {
+	if neighbors := mappingValue(documentMapping(root), "neighbors"); neighbors != nil && neighbors.Kind == yaml.SequenceNode {
+		for i, n := range neighbors.Content {
+			if n.Kind != yaml.MappingNode {
+				continue
+			}
+			key, name := reference(n, "peerGroup")
+			if key == nil {
+				continue
+			}
+			def, ok := defs.peerGroups[name]
+			if !ok {
+				*errs = append(*errs, &validation.Error{Line: key.Line, Column: key.Column, Err: fmt.Errorf("unknown peer group %q", name)})
+				continue
+			}
+			neighbors.Content[i] = merge(def, n)
+		}
+	}
+
+	prefixes := mappingValue(documentMapping(root), "prefixes")
+	if prefixes == nil || prefixes.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, p := range prefixes.Content {
+		if p.Kind != yaml.MappingNode {
+			continue
+		}
+		if key, name := reference(p, "template"); key != nil {
+			def, ok := defs.templates[name]
+			if !ok {
+				*errs = append(*errs, &validation.Error{Line: key.Line, Column: key.Column, Err: fmt.Errorf("unknown prefix template %q", name)})
+				continue
+			}
+			p = merge(def, p)
+			prefixes.Content[i] = p
+		}
+		for _, probeKey := range probeKeys {
+			n := mappingValue(p, probeKey)
+			if n == nil || n.Kind != yaml.MappingNode {
+				continue
+			}
+			key, name := reference(n, "check")
+			if key == nil {
+				continue
+			}
+			def, ok := defs.checks[name]
+			if !ok {
+				*errs = append(*errs, &validation.Error{Line: key.Line, Column: key.Column, Err: fmt.Errorf("unknown check %q", name)})
+				continue
+			}
+			setMappingValue(p, probeKey, merge(def, n))
+		}
+	}
+}
+
+// reference returns the value node of key in mapping n and its string value.
+func reference(n *yaml.Node, key string) (*yaml.Node, string) {
+	v := mappingValue(n, key)
+	if v == nil || v.Kind != yaml.ScalarNode {
+		return nil, ""
+	}
+	return v, v.Value
+}
+
+// merge returns base overridden by over. Nodes are shared, not copied.
+func merge(base, over *yaml.Node) *yaml.Node {
+	if base.Kind == yaml.AliasNode {
+		base = base.Alias
+	}
+	if over.Kind == yaml.AliasNode {
+		over = over.Alias
+	}
+	if base.Kind != yaml.MappingNode || over.Kind != yaml.MappingNode {
+		return over
+	}
+
+	merged := *over
+	merged.Content = nil
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		key := base.Content[i]
+		value := base.Content[i+1]
+		if o := mappingValue(over, key.Value); o != nil {
+			value = merge(value, o)
+			key = mappingKey(over, key.Value)
+		}
+		merged.Content = append(merged.Content, key, value)
+	}
+	for i := 0; i+1 < len(over.Content); i += 2 {
+		if mappingValue(base, over.Content[i].Value) == nil {
+			merged.Content = append(merged.Content, over.Content[i], over.Content[i+1])
+		}
+	}
+	return &merged
+}
+
+func documentMapping(root *yaml.Node) *yaml.Node {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		return root.Content[0]
+	}
+	return root
+}
+
+func mappingKey(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i]
+		}
+	}
+	return nil
+}
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(n *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			n.Content[i+1] = value
+			return
+		}
+	}
+}
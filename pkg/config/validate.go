@@ -0,0 +1,722 @@
+package config
+
+import (
+	"maps"
+	"math"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/ahmet2mir/herald/pkg/address"
+	"github.com/ahmet2mir/herald/pkg/community"
+	"github.com/ahmet2mir/herald/pkg/validation"
+)
+
+// Default values applied by Validate.
+const (
+	DefaultBFDListenAddress  = "0.0.0.0"
+	DefaultBFDListenPort     = 3784
+	DefaultBFDInterval       = 1 * time.Second
+	DefaultBFDDetectionMult  = 3
+	DefaultMetricsAddress    = "127.0.0.1"
+	DefaultMetricsInterval   = 15 * time.Second
+	DefaultMetricsListenPort = 9091
+	DefaultBMPPort           = 11019
+	DefaultBMPPolicy         = BMPLocalRIB
+	DefaultMRTRotation       = time.Hour
+	minMRTRotation           = time.Minute
+	DefaultHoldTime          = 90 * time.Second
+	DefaultConnectRetry      = 120 * time.Second
+	DefaultTTL               = 255
+	DefaultOrigin            = OriginIGP
+	DefaultDrainPeriod       = 30 * time.Second
+	DefaultReconcileInterval = time.Minute
+)
+
+// Values of Prefix.Origin.
+const (
+	OriginIGP        = "igp"
+	OriginEGP        = "egp"
+	OriginIncomplete = "incomplete"
+)
+
+// maxASPathSegment is the number of AS numbers an AS_PATH segment can hold.
+const maxASPathSegment = 255
+
+// Validate applies defaults and checks the configuration for values that
+// would make herald fail at runtime. It does not open any connection. The
+// returned error is a validation.Errors listing every problem found.
+func (c *Config) Validate() error {
+	var errs validation.Errors
+
+	c.Logging.Validate("logging", &errs)
+	c.Speaker.validate("speaker", &errs)
+	c.API.validate("api", &errs)
+	if c.Metrics != nil {
+		c.Metrics.validate("metrics", &errs)
+	}
+	if c.BFD != nil {
+		c.BFD.validate("bfd", &errs)
+	}
+	if c.BMP != nil {
+		c.BMP.validate("bmp", &errs)
+	}
+	if c.MRT != nil {
+		c.MRT.validate("mrt", &errs)
+	}
+
+	for i := range c.Neighbors {
+		c.Neighbors[i].validate(validation.Index("neighbors", i), &errs)
+	}
+	for i := range c.Prefixes {
+		c.Prefixes[i].validate(validation.Index("prefixes", i), &errs)
+	}
+
+	neighbors := map[string]int{}
+	for i, n := range c.Neighbors {
+		if n.Passive && c.Speaker.ListenPort == 0 {
+			errs.Addf(validation.Field(validation.Index("neighbors", i), "passive"), "requires speaker.listenPort, herald does not accept connections otherwise")
+		}
+		if j, ok := neighbors[n.Address]; ok {
+			errs.Addf(validation.Field(validation.Index("neighbors", i), "address"),
+				"duplicate neighbor %s, already defined at %s", n.Address, c.describe(validation.Index("neighbors", j)))
+			continue
+		}
+		neighbors[n.Address] = i
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.PeerGroups)) {
+		g := c.PeerGroups[name]
+		if g.Address != "" {
+			errs.Addf(validation.Field(validation.Field("peerGroups", name), "address"), "is set by each neighbor of the peer group")
+		}
+		if g.PeerGroup != "" {
+			errs.Addf(validation.Field(validation.Field("peerGroups", name), "peerGroup"), "peer groups cannot be nested")
+		}
+	}
+	groups := map[string]bool{}
+	for i := range c.DynamicNeighbors {
+		c.validateDynamic(i, groups, &errs)
+	}
+	prefixes := map[string]int{}
+	for i, p := range c.Prefixes {
+		if j, ok := prefixes[p.IPAddress]; ok {
+			errs.Addf(validation.Field(validation.Index("prefixes", i), "ipAddress"),
+				"duplicate prefix %s, already defined at %s", p.IPAddress, c.describe(validation.Index("prefixes", j)))
+			continue
+		}
+		prefixes[p.IPAddress] = i
+	}
+
+	// A prefix whose family no neighbor negotiates is never announced.
+	families := map[string]bool{}
+	ibgp := false
+	addresses := map[string]bool{}
+	for _, n := range c.Neighbors {
+		for _, family := range n.Families {
+			families[family] = true
+		}
+		ibgp = ibgp || n.ASN == c.Speaker.ASN
+		if ip := net.ParseIP(n.Address); ip != nil {
+			addresses[ip.String()] = true
+		}
+		if n.PeerGroup != "" {
+			groups[n.PeerGroup] = true
+		}
+	}
+	for _, d := range c.DynamicNeighbors {
+		g := c.PeerGroups[d.PeerGroup]
+		for _, family := range g.Families {
+			families[family] = true
+		}
+		ibgp = ibgp || g.ASN == c.Speaker.ASN
+	}
+	// Prefixes can only select configured neighbors and peer groups with
+	// neighbors.
+	validateSelector := func(path string, selector []string) {
+		for j, address := range selector {
+			ip := net.ParseIP(address)
+			_, group := c.PeerGroups[address]
+			switch {
+			case ip == nil && groups[address]:
+			case ip == nil && group:
+				errs.Addf(validation.Index(path, j), "peer group %s has no neighbors nor dynamic neighbors", address)
+			case ip == nil:
+				errs.Addf(validation.Index(path, j), "must be an IP address or a peer group, got %q", address)
+			case !addresses[ip.String()]:
+				errs.Addf(validation.Index(path, j), "%s is not a configured neighbor", address)
+			}
+		}
+	}
+	for i, p := range c.Prefixes {
+		validateSelector(validation.Field(validation.Index("prefixes", i), "neighbors"), p.Neighbors)
+		if rc := p.RouteCondition; rc != nil {
+			validateSelector(validation.Field(validation.Field(validation.Index("prefixes", i), "routeCondition"), "neighbors"), rc.Neighbors)
+		}
+		for j, o := range p.NeighborOverrides {
+			validateSelector(validation.Field(validation.Index(validation.Field(validation.Index("prefixes", i), "neighborOverrides"), j), "neighbors"), o.Neighbors)
+		}
+	}
+	for i, p := range c.Prefixes {
+		if family := p.Family(); len(c.Neighbors) > 0 && family != "" && !families[family] {
+			errs.Addf(validation.Field(validation.Index("prefixes", i), "ipAddress"),
+				"no neighbor negotiates %s, add it to the families of a neighbor", family)
+		}
+		// The speaker AS number is prepended for eBGP neighbors.
+		degraded := p.DegradedPrefix()
+		length := len(degraded.ASPath(c.Speaker.ASN)) + 1
+		overridden := 0
+		for _, o := range p.NeighborOverrides {
+			overridden = max(overridden, len(o.AsPathPrepend))
+		}
+		if length += overridden; length > maxASPathSegment {
+			errs.Addf(validation.Field(validation.Index("prefixes", i), "asPathPrepend"),
+				"AS path would hold %d AS numbers, at most %d are allowed", length, maxASPathSegment)
+		}
+		// The number of sessions accepted from dynamic neighbor ranges is
+		// not known in advance.
+		dynamic := slices.ContainsFunc(c.DynamicNeighbors, func(d DynamicNeighbor) bool {
+			return len(p.Neighbors) == 0 || slices.Contains(p.Neighbors, d.PeerGroup)
+		})
+		selected := 0
+		for _, n := range c.Neighbors {
+			if c.Selects(p.Neighbors, n.Address) {
+				selected++
+			}
+		}
+		if !dynamic && p.MinEstablishedPeers > selected {
+			errs.Addf(validation.Field(validation.Index("prefixes", i), "minEstablishedPeers"),
+				"must be at most the %d neighbors of the prefix, got %d", selected, p.MinEstablishedPeers)
+		}
+		// GoBGP sets the local address of the session as next hop, which
+		// must be in the family of the prefix.
+		if p.NextHop == "" && p.NextHopInterface == "" && p.Family() == FamilyIPv6Unicast {
+			for _, n := range c.Neighbors {
+				if c.Selects(p.Neighbors, n.Address) && slices.Contains(n.Families, FamilyIPv6Unicast) && ipv4Session(n) {
+					errs.Addf(validation.Field(validation.Index("prefixes", i), "nextHop"),
+						"is required for IPv6 prefixes announced to neighbor %s over IPv4, or set nextHopInterface", n.Address)
+				}
+			}
+			for _, d := range c.DynamicNeighbors {
+				ip, _, err := net.ParseCIDR(d.Prefix)
+				if err != nil {
+					continue
+				}
+				n := c.PeerGroups[d.PeerGroup]
+				n.Address = ip.String()
+				if (len(p.Neighbors) == 0 || slices.Contains(p.Neighbors, d.PeerGroup)) && slices.Contains(n.Families, FamilyIPv6Unicast) && ipv4Session(n) {
+					errs.Addf(validation.Field(validation.Index("prefixes", i), "nextHop"),
+						"is required for IPv6 prefixes announced to dynamic neighbors %s over IPv4, or set nextHopInterface", d.Prefix)
+				}
+			}
+		}
+		// GoBGP removes LOCAL_PREF from routes sent to eBGP neighbors.
+		if p.LocalPreference != 0 && len(c.Neighbors) > 0 && !ibgp {
+			errs.Addf(validation.Field(validation.Index("prefixes", i), "localPreference"),
+				"only applies to iBGP neighbors, no neighbor has asn %d", c.Speaker.ASN)
+		}
+	}
+
+	return errs.Err()
+}
+
+func (s *Speaker) validate(path string, errs *validation.Errors) {
+	if s.ASN == 0 {
+		errs.Addf(validation.Field(path, "asn"), "is required")
+	}
+	if ip := net.ParseIP(s.RouterID); ip == nil || ip.To4() == nil {
+		errs.Addf(validation.Field(path, "routerId"), "must be an IPv4 address, got %q", s.RouterID)
+	}
+	if s.GracefulRestartRestartTime > 4095 {
+		errs.Addf(validation.Field(path, "gracefulRestartRestartTime"), "must be at most 4095 seconds, got %d", s.GracefulRestartRestartTime)
+	}
+	if s.ListenPort != 0 {
+		validatePort(validation.Field(path, "listenPort"), int(s.ListenPort), errs)
+	}
+	for i, address := range s.ListenAddresses {
+		if net.ParseIP(address) == nil {
+			errs.Addf(validation.Index(validation.Field(path, "listenAddresses"), i), "must be an IP address, got %q", address)
+		}
+	}
+	if gs := s.GracefulShutdown; gs != nil {
+		if gs.DrainPeriod == 0 {
+			gs.DrainPeriod = DefaultDrainPeriod
+		}
+		if gs.DrainPeriod < 0 {
+			errs.Addf(validation.Field(validation.Field(path, "gracefulShutdown"), "drainPeriod"), "must not be negative, got %s", gs.DrainPeriod)
+		}
+	}
+	if r := s.Reconciliation; r != nil {
+		if r.Interval == 0 {
+			r.Interval = DefaultReconcileInterval
+		}
+		if r.Interval < time.Second {
+			errs.Addf(validation.Field(validation.Field(path, "reconciliation"), "interval"), "must be at least 1s, got %s", r.Interval)
+		}
+	}
+}
+
+func (ca *ConfigAPI) validate(path string, errs *validation.Errors) {
+	if net.ParseIP(ca.ListenAddress) == nil {
+		errs.Addf(validation.Field(path, "listenAddress"), "must be an IP address, got %q", ca.ListenAddress)
+	}
+	validatePort(validation.Field(path, "listenPort"), ca.ListenPort, errs)
+}
+
+func (mc *MetricsConfig) validate(path string, errs *validation.Errors) {
+	if mc.Interval == 0 {
+		mc.Interval = DefaultMetricsInterval
+	}
+	if mc.ListenAddress == "" {
+		mc.ListenAddress = DefaultMetricsAddress
+	}
+	if mc.ListenPort == 0 {
+		mc.ListenPort = DefaultMetricsListenPort
+	}
+	if mc.Interval < 0 {
+		errs.Addf(validation.Field(path, "interval"), "must not be negative, got %s", mc.Interval)
+	}
+	if net.ParseIP(mc.ListenAddress) == nil {
+		errs.Addf(validation.Field(path, "listenAddress"), "must be an IP address, got %q", mc.ListenAddress)
+	}
+	validatePort(validation.Field(path, "listenPort"), mc.ListenPort, errs)
+}
+
+func (bc *BMPConfig) validate(path string, errs *validation.Errors) {
+	if bc.Port == 0 {
+		bc.Port = DefaultBMPPort
+	}
+	if bc.RouteMonitoringPolicy == "" {
+		bc.RouteMonitoringPolicy = DefaultBMPPolicy
+	}
+
+	if bc.Enabled && bc.Address == "" {
+		errs.Addf(validation.Field(path, "address"), "is required")
+	}
+	validatePort(validation.Field(path, "port"), bc.Port, errs)
+	switch bc.RouteMonitoringPolicy {
+	case BMPPrePolicy, BMPPostPolicy, BMPBoth, BMPLocalRIB, BMPAll:
+	default:
+		errs.Addf(validation.Field(path, "routeMonitoringPolicy"), "must be %s, %s, %s, %s or %s, got %q",
+			BMPPrePolicy, BMPPostPolicy, BMPBoth, BMPLocalRIB, BMPAll, bc.RouteMonitoringPolicy)
+	}
+	if bc.StatisticsInterval < 0 || bc.StatisticsInterval > math.MaxUint16*time.Second || bc.StatisticsInterval%time.Second != 0 {
+		errs.Addf(validation.Field(path, "statisticsInterval"), "must be whole seconds between 0 and %d, got %s", math.MaxUint16, bc.StatisticsInterval)
+	}
+}
+
+func (mc *MRTConfig) validate(path string, errs *validation.Errors) {
+	if mc.Enabled && mc.Updates == nil && mc.Table == nil {
+		errs.Addf(path, "requires updates or table")
+	}
+	if mc.Updates != nil {
+		mc.Updates.validate(validation.Field(path, "updates"), errs)
+	}
+	if mc.Table != nil {
+		mc.Table.validate(validation.Field(path, "table"), errs)
+	}
+	if mc.Updates != nil && mc.Table != nil && mc.Updates.FileName == mc.Table.FileName {
+		errs.Addf(validation.Field(validation.Field(path, "table"), "fileName"), "must differ from updates.fileName")
+	}
+}
+
+func (d *MRTDump) validate(path string, errs *validation.Errors) {
+	if d.RotationInterval == 0 {
+		d.RotationInterval = DefaultMRTRotation
+	}
+	if d.FileName == "" {
+		errs.Addf(validation.Field(path, "fileName"), "is required")
+	}
+	if d.RotationInterval < minMRTRotation || d.RotationInterval%time.Second != 0 {
+		errs.Addf(validation.Field(path, "rotationInterval"), "must be whole seconds of at least %s, got %s", minMRTRotation, d.RotationInterval)
+	}
+}
+
+func (bc *BFDConfig) validate(path string, errs *validation.Errors) {
+	if bc.ListenAddress == "" {
+		bc.ListenAddress = DefaultBFDListenAddress
+	}
+	if bc.ListenPort == 0 {
+		bc.ListenPort = DefaultBFDListenPort
+	}
+	if bc.MinimumReceptionInterval == 0 {
+		bc.MinimumReceptionInterval = DefaultBFDInterval
+	}
+	if bc.MinimumTransmissionInterval == 0 {
+		bc.MinimumTransmissionInterval = DefaultBFDInterval
+	}
+	if bc.DetectionMultiplier == 0 {
+		bc.DetectionMultiplier = DefaultBFDDetectionMult
+	}
+
+	if net.ParseIP(bc.ListenAddress) == nil {
+		errs.Addf(validation.Field(path, "listenAddress"), "must be an IP address, got %q", bc.ListenAddress)
+	}
+	validatePort(validation.Field(path, "listenPort"), bc.ListenPort, errs)
+	if bc.MinimumReceptionInterval < 0 {
+		errs.Addf(validation.Field(path, "minimumReceptionInterval"), "must not be negative, got %s", bc.MinimumReceptionInterval)
+	}
+	if bc.MinimumTransmissionInterval < 0 {
+		errs.Addf(validation.Field(path, "minimumTransmissionInterval"), "must not be negative, got %s", bc.MinimumTransmissionInterval)
+	}
+}
+
+func (n *Neighbor) validate(path string, errs *validation.Errors) {
+	address := net.ParseIP(n.Address)
+	if address == nil {
+		errs.Addf(validation.Field(path, "address"), "must be an IP address, got %q", n.Address)
+	} else if len(n.Families) == 0 {
+		n.Families = []string{addressFamily(address)}
+	}
+	seen := map[string]bool{}
+	for i, family := range n.Families {
+		switch {
+		case family != FamilyIPv4Unicast && family != FamilyIPv6Unicast:
+			errs.Addf(validation.Index(validation.Field(path, "families"), i), "unsupported family %q, expected %s or %s", family, FamilyIPv4Unicast, FamilyIPv6Unicast)
+		case seen[family]:
+			errs.Addf(validation.Index(validation.Field(path, "families"), i), "duplicate family %q", family)
+		}
+		seen[family] = true
+	}
+	if n.ASN == 0 {
+		errs.Addf(validation.Field(path, "asn"), "is required")
+	}
+	if err := n.Password.Resolve(); err != nil {
+		errs.Add(validation.Field(validation.Field(path, "password"), "valueFrom"), err)
+	}
+	if len(n.Password.String) > 80 {
+		errs.Addf(validation.Field(path, "password"), "must be at most 80 characters")
+	}
+
+	if n.LocalAddress != "" {
+		local := net.ParseIP(n.LocalAddress)
+		switch {
+		case local == nil:
+			errs.Addf(validation.Field(path, "localAddress"), "must be an IP address, got %q", n.LocalAddress)
+		case address != nil && (local.To4() == nil) != (address.To4() == nil):
+			errs.Addf(validation.Field(path, "localAddress"), "address family of %q does not match address %q", n.LocalAddress, n.Address)
+		}
+	}
+
+	if n.EbgpMultihopEnabled && n.EbgpMultihopTTL == 0 {
+		n.EbgpMultihopTTL = DefaultTTL
+	}
+	if !n.EbgpMultihopEnabled && n.EbgpMultihopTTL != 0 {
+		errs.Addf(validation.Field(path, "ebgpMultihopTtl"), "requires ebgpMultihopEnabled")
+	}
+	if n.TTLSecurityEnabled && n.TTLMin == 0 {
+		n.TTLMin = DefaultTTL
+	}
+	if !n.TTLSecurityEnabled && n.TTLMin != 0 {
+		errs.Addf(validation.Field(path, "ttlMin"), "requires ttlSecurityEnabled")
+	}
+	if n.TTLSecurityEnabled && n.EbgpMultihopEnabled {
+		errs.Addf(validation.Field(path, "ttlSecurityEnabled"), "cannot be combined with ebgpMultihopEnabled, lower ttlMin to allow multihop peers")
+	}
+
+	if n.HoldTime == 0 {
+		n.HoldTime = DefaultHoldTime
+	}
+	if n.KeepaliveInterval == 0 {
+		// BGP timers are whole seconds.
+		n.KeepaliveInterval = max((n.HoldTime / 3).Truncate(time.Second), time.Second)
+	}
+	if n.ConnectRetry == 0 {
+		n.ConnectRetry = DefaultConnectRetry
+	}
+	validateSeconds(validation.Field(path, "holdTime"), n.HoldTime, errs)
+	validateSeconds(validation.Field(path, "keepaliveInterval"), n.KeepaliveInterval, errs)
+	validateSeconds(validation.Field(path, "connectRetry"), n.ConnectRetry, errs)
+	if n.HoldTime < 3*time.Second {
+		errs.Addf(validation.Field(path, "holdTime"), "must be at least 3s, got %s", n.HoldTime)
+	}
+	if n.HoldTime > 65535*time.Second {
+		errs.Addf(validation.Field(path, "holdTime"), "must be at most 65535s, got %s", n.HoldTime)
+	}
+	if n.KeepaliveInterval >= n.HoldTime {
+		errs.Addf(validation.Field(path, "keepaliveInterval"), "must be lower than holdTime %s, got %s", n.HoldTime, n.KeepaliveInterval)
+	}
+}
+
+// validateDynamic checks the i-th dynamic neighbor and records its peer
+// group in groups. The peer group is validated and defaulted as a neighbor
+// in the range the first time it is used.
+func (c *Config) validateDynamic(i int, groups map[string]bool, errs *validation.Errors) {
+	path := validation.Index("dynamicNeighbors", i)
+	d := c.DynamicNeighbors[i]
+	if c.Speaker.ListenPort == 0 {
+		errs.Addf(path, "requires speaker.listenPort, herald does not accept connections otherwise")
+	}
+	_, network, err := net.ParseCIDR(d.Prefix)
+	if err != nil {
+		errs.Addf(validation.Field(path, "prefix"), "must be a prefix in CIDR notation, got %q", d.Prefix)
+	}
+	for j, other := range c.DynamicNeighbors[:i] {
+		if other.Prefix == d.Prefix {
+			errs.Addf(validation.Field(path, "prefix"), "duplicate dynamic neighbor %s, already defined at %s", d.Prefix, c.describe(validation.Index("dynamicNeighbors", j)))
+		}
+	}
+	g, ok := c.PeerGroups[d.PeerGroup]
+	switch {
+	case d.PeerGroup == "":
+		errs.Addf(validation.Field(path, "peerGroup"), "is required")
+		return
+	case !ok:
+		errs.Addf(validation.Field(path, "peerGroup"), "unknown peer group %q", d.PeerGroup)
+		return
+	case err == nil && !groups[d.PeerGroup] && g.Address == "":
+		g.Address = network.IP.String()
+		g.validate(validation.Field("peerGroups", d.PeerGroup), errs)
+		g.Address = ""
+		c.PeerGroups[d.PeerGroup] = g
+	}
+	if err == nil {
+		groups[d.PeerGroup] = true
+	}
+}
+
+// ipv4Session reports whether the BGP session with n runs over IPv4.
+func ipv4Session(n Neighbor) bool {
+	address := n.LocalAddress
+	if address == "" {
+		address = n.Address
+	}
+	ip := net.ParseIP(address)
+	return ip != nil && ip.To4() != nil
+}
+
+// validateSeconds checks that d is a positive whole number of seconds, as BGP
+// timers are.
+func validateSeconds(path string, d time.Duration, errs *validation.Errors) {
+	if d < 0 || d%time.Second != 0 {
+		errs.Addf(path, "must be a positive number of seconds, got %s", d)
+	}
+}
+
+func (p *Prefix) validate(path string, errs *validation.Errors) {
+	ip, _, err := net.ParseCIDR(p.IPAddress)
+	if err != nil {
+		errs.Addf(validation.Field(path, "ipAddress"), "must be a prefix in CIDR notation, got %q", p.IPAddress)
+	}
+	if p.Name == "" {
+		p.Name = p.IPAddress
+	}
+
+	nextHop := net.ParseIP(p.NextHop)
+	switch {
+	case p.NextHop == "":
+	case nextHop == nil:
+		errs.Addf(validation.Field(path, "nextHop"), "must be an IP address, got %q", p.NextHop)
+	case ip != nil && (ip.To4() == nil) != (nextHop.To4() == nil):
+		errs.Addf(validation.Field(path, "nextHop"), "address family of %q does not match ipAddress %q", p.NextHop, p.IPAddress)
+	}
+	if p.NextHopInterface != "" {
+		if p.NextHop != "" {
+			errs.Addf(validation.Field(path, "nextHopInterface"), "cannot be set with nextHop")
+		}
+		if err := address.ValidateName(p.NextHopInterface); err != nil {
+			errs.Add(validation.Field(path, "nextHopInterface"), err)
+		}
+	}
+	if p.NextHopLinkLocal != "" && p.NextHop == "" && p.NextHopInterface == "" {
+		errs.Addf(validation.Field(path, "nextHopLinkLocal"), "requires nextHop or nextHopInterface")
+	}
+	if p.NextHopLinkLocal != "" {
+		linkLocal := net.ParseIP(p.NextHopLinkLocal)
+		switch {
+		case linkLocal == nil || linkLocal.To4() != nil || !linkLocal.IsLinkLocalUnicast():
+			errs.Addf(validation.Field(path, "nextHopLinkLocal"), "must be an IPv6 link-local address, got %q", p.NextHopLinkLocal)
+		case ip != nil && ip.To4() != nil:
+			errs.Addf(validation.Field(path, "nextHopLinkLocal"), "only applies to IPv6 prefixes, ipAddress is %q", p.IPAddress)
+		}
+	}
+
+	if p.Interface != "" {
+		if err := address.ValidateName(p.Interface); err != nil {
+			errs.Add(validation.Field(path, "interface"), err)
+		}
+	} else if p.KeepAddress {
+		errs.Addf(validation.Field(path, "keepAddress"), "requires interface")
+	}
+
+	if p.Origin == "" {
+		p.Origin = DefaultOrigin
+	}
+	switch p.Origin {
+	case OriginIGP, OriginEGP, OriginIncomplete:
+	default:
+		errs.Addf(validation.Field(path, "origin"), "must be %s, %s or %s, got %q", OriginIGP, OriginEGP, OriginIncomplete, p.Origin)
+	}
+	for i, asn := range p.AsPathPrepend {
+		if asn == 0 {
+			errs.Addf(validation.Index(validation.Field(path, "asPathPrepend"), i), "AS number 0 is reserved (RFC 7607)")
+		}
+	}
+
+	for i, c := range p.Communities {
+		if _, err := community.ParseStandard(c); err != nil {
+			errs.Add(validation.Index(validation.Field(path, "communities"), i), err)
+		}
+	}
+	for i, c := range p.LargeCommunities {
+		if _, err := community.ParseLarge(c); err != nil {
+			errs.Add(validation.Index(validation.Field(path, "largeCommunities"), i), err)
+		}
+	}
+	for i, c := range p.ExtendedCommunities {
+		if _, err := community.ParseExtended(c); err != nil {
+			errs.Add(validation.Index(validation.Field(path, "extendedCommunities"), i), err)
+		}
+	}
+
+	if p.Service != nil {
+		p.Service.Validate(validation.Field(path, "service"), errs)
+	}
+
+	if p.StartupProbe != nil {
+		probePath := validation.Field(path, "startupProbe")
+		p.StartupProbe.Validate(probePath, errs)
+		if p.StartupProbe.SuccessThreshold != 1 {
+			errs.Addf(validation.Field(probePath, "successThreshold"), "must be 1 for startup probes, got %d", p.StartupProbe.SuccessThreshold)
+		}
+	}
+	if p.LivenessProbe != nil {
+		probePath := validation.Field(path, "livenessProbe")
+		p.LivenessProbe.Validate(probePath, errs)
+		if p.LivenessProbe.SuccessThreshold != 1 {
+			errs.Addf(validation.Field(probePath, "successThreshold"), "must be 1 for liveness probes, got %d", p.LivenessProbe.SuccessThreshold)
+		}
+	}
+	if p.ReadinessProbe != nil {
+		p.ReadinessProbe.Validate(validation.Field(path, "readinessProbe"), errs)
+	}
+	for i := range p.NeighborOverrides {
+		p.NeighborOverrides[i].validate(validation.Index(validation.Field(path, "neighborOverrides"), i), errs)
+	}
+
+	if p.DegradedProbe != nil {
+		p.DegradedProbe.Validate(validation.Field(path, "degradedProbe"), errs)
+		if p.Degraded == nil {
+			errs.Addf(validation.Field(path, "degradedProbe"), "requires degraded")
+		}
+	}
+	if p.Degraded != nil {
+		p.Degraded.validate(validation.Field(path, "degraded"), errs)
+		if p.ReadinessProbe == nil {
+			errs.Addf(validation.Field(path, "degraded"), "requires readinessProbe")
+		}
+	}
+	if p.WithdrawOnDown == nil {
+		withdrawOnDown := p.Degraded == nil
+		p.WithdrawOnDown = &withdrawOnDown
+	}
+	if *p.WithdrawOnDown && p.Degraded != nil && p.Degraded.WithdrawThreshold > 0 {
+		errs.Addf(validation.Field(validation.Field(path, "degraded"), "withdrawThreshold"), "requires withdrawOnDown false")
+	}
+	if p.BandwidthProbe != nil {
+		probePath := validation.Field(path, "bandwidthProbe")
+		p.BandwidthProbe.Validate(probePath, errs)
+		if p.BandwidthProbe.ProbeHTTP == nil && p.BandwidthProbe.ProbeExec == nil {
+			errs.Addf(probePath, "must be an http or exec probe, the others have no output")
+		}
+		if p.LinkBandwidth == nil {
+			errs.Addf(probePath, "requires linkBandwidth")
+		}
+	}
+	if lb := p.LinkBandwidth; lb != nil {
+		lbPath := validation.Field(path, "linkBandwidth")
+		switch {
+		case lb.Bandwidth != "":
+			if _, err := ParseBandwidth(lb.Bandwidth); err != nil {
+				errs.Add(validation.Field(lbPath, "bandwidth"), err)
+			}
+		case p.BandwidthProbe == nil:
+			errs.Addf(lbPath, "requires bandwidth or bandwidthProbe")
+		}
+		if lb.ASN > math.MaxUint16 {
+			errs.Addf(validation.Field(lbPath, "asn"), "must fit in 16 bits, got %d", lb.ASN)
+		}
+	}
+	if p.MinEstablishedPeers < 0 {
+		errs.Addf(validation.Field(path, "minEstablishedPeers"), "must not be negative, got %d", p.MinEstablishedPeers)
+	} else if p.MinEstablishedPeers > 0 && p.ReadinessProbe == nil {
+		errs.Addf(validation.Field(path, "minEstablishedPeers"), "requires readinessProbe")
+	}
+	if p.RouteCondition != nil {
+		p.RouteCondition.validate(validation.Field(path, "routeCondition"), errs)
+		if p.ReadinessProbe == nil {
+			errs.Addf(validation.Field(path, "routeCondition"), "requires readinessProbe")
+		}
+	}
+	if p.Maintenance != "" && p.ReadinessProbe == nil {
+		errs.Addf(validation.Field(path, "maintenance"), "requires readinessProbe")
+	}
+}
+
+func (o *NeighborOverride) validate(path string, errs *validation.Errors) {
+	if len(o.Neighbors) == 0 {
+		errs.Addf(validation.Field(path, "neighbors"), "is required")
+	}
+	if o.MultiExitDescriminator == 0 && len(o.AsPathPrepend) == 0 && len(o.Communities) == 0 && len(o.LargeCommunities) == 0 {
+		errs.Addf(path, "must set at least one of multiExitDescriminator, asPathPrepend, communities or largeCommunities")
+	}
+	for i, asn := range o.AsPathPrepend {
+		switch {
+		case asn == 0:
+			errs.Addf(validation.Index(validation.Field(path, "asPathPrepend"), i), "AS number 0 is reserved (RFC 7607)")
+		case asn != o.AsPathPrepend[0]:
+			// GoBGP policies prepend a single AS number, repeated.
+			errs.Addf(validation.Index(validation.Field(path, "asPathPrepend"), i), "must repeat AS number %d, got %d", o.AsPathPrepend[0], asn)
+		}
+	}
+	for i, c := range o.Communities {
+		if _, err := community.ParseStandard(c); err != nil {
+			errs.Add(validation.Index(validation.Field(path, "communities"), i), err)
+		}
+	}
+	for i, c := range o.LargeCommunities {
+		if _, err := community.ParseLarge(c); err != nil {
+			errs.Add(validation.Index(validation.Field(path, "largeCommunities"), i), err)
+		}
+	}
+}
+
+func (rc *RouteCondition) validate(path string, errs *validation.Errors) {
+	if len(rc.Prefixes) == 0 {
+		errs.Addf(validation.Field(path, "prefixes"), "is required")
+	}
+	for i, prefix := range rc.Prefixes {
+		if _, _, err := net.ParseCIDR(prefix); err != nil {
+			errs.Addf(validation.Index(validation.Field(path, "prefixes"), i), "must be a prefix in CIDR notation, got %q", prefix)
+		}
+	}
+}
+
+func (d *Degraded) validate(path string, errs *validation.Errors) {
+	if d.MultiExitDescriminator == 0 && len(d.AsPathPrepend) == 0 && len(d.Communities) == 0 && len(d.LargeCommunities) == 0 {
+		errs.Addf(path, "must set at least one of multiExitDescriminator, asPathPrepend, communities or largeCommunities")
+	}
+	for i, asn := range d.AsPathPrepend {
+		if asn == 0 {
+			errs.Addf(validation.Index(validation.Field(path, "asPathPrepend"), i), "AS number 0 is reserved (RFC 7607)")
+		}
+	}
+	for i, c := range d.Communities {
+		if _, err := community.ParseStandard(c); err != nil {
+			errs.Add(validation.Index(validation.Field(path, "communities"), i), err)
+		}
+	}
+	for i, c := range d.LargeCommunities {
+		if _, err := community.ParseLarge(c); err != nil {
+			errs.Add(validation.Index(validation.Field(path, "largeCommunities"), i), err)
+		}
+	}
+	if d.WithdrawThreshold < 0 {
+		errs.Addf(validation.Field(path, "withdrawThreshold"), "must not be negative, got %d", d.WithdrawThreshold)
+	}
+}
+
+func validatePort(path string, port int, errs *validation.Errors) {
+	if port < 1 || port > 65535 {
+		errs.Addf(path, "must be between 1 and 65535, got %d", port)
+	}
+}
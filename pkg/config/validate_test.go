@@ -0,0 +1,241 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmet2mir/herald/pkg/probe"
+	"github.com/ahmet2mir/herald/pkg/validation"
+)
+
+const testHeader = `api:
+  listenAddress: 127.0.0.1
+  listenPort: 50051
+speaker:
+  asn: 64600
+  routerId: 10.0.0.1
+`
+
+// writeConfig writes testHeader followed by body to a file and returns its
+// path.
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "herald.yaml")
+	if err := os.WriteFile(path, []byte(testHeader+body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateErrors(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secret, []byte(strings.Repeat("x", 81)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		// want lists the errors as "line:column: path: message".
+		want []string
+	}{
+		{
+			name: "valid",
+			body: `neighbors:
+  - address: 192.0.2.1
+    asn: 64601
+prefixes:
+  - ipAddress: 198.51.100.1/32
+    nextHop: 10.0.0.1
+`,
+		},
+		{
+			name: "neighbor address and asn",
+			body: `neighbors:
+  - address: spine1
+`,
+			want: []string{
+				"8:5: neighbors[0].address: must be an IP address, got \"spine1\"",
+				"8:5: neighbors[0].asn: is required",
+			},
+		},
+		{
+			name: "keepalive not lower than hold time",
+			body: `neighbors:
+  - address: 192.0.2.1
+    asn: 64601
+    holdTime: 9s
+    keepaliveInterval: 9s
+`,
+			want: []string{"11:5: neighbors[0].keepaliveInterval: must be lower than holdTime 9s, got 9s"},
+		},
+		{
+			name: "hold time too short",
+			body: `neighbors:
+  - address: 192.0.2.1
+    asn: 64601
+    holdTime: 2s
+`,
+			want: []string{"10:5: neighbors[0].holdTime: must be at least 3s, got 2s"},
+		},
+		{
+			name: "password file missing",
+			body: `neighbors:
+  - address: 192.0.2.1
+    asn: 64601
+    password:
+      valueFrom:
+        file: /nonexistent/password
+`,
+			want: []string{"11:7: neighbors[0].password.valueFrom: failed to read secret file: open /nonexistent/password: no such file or directory"},
+		},
+		{
+			name: "password file too long",
+			body: `neighbors:
+  - address: 192.0.2.1
+    asn: 64601
+    password:
+      valueFrom:
+        file: ` + secret + `
+`,
+			want: []string{"10:5: neighbors[0].password: must be at most 80 characters"},
+		},
+		{
+			name: "passive without listen port",
+			body: `neighbors:
+  - address: 192.0.2.1
+    asn: 64601
+    passive: true
+`,
+			want: []string{"10:5: neighbors[0].passive: requires speaker.listenPort, herald does not accept connections otherwise"},
+		},
+		{
+			name: "prefix neighbor not configured",
+			body: `neighbors:
+  - address: 192.0.2.1
+    asn: 64601
+prefixes:
+  - ipAddress: 198.51.100.1/32
+    nextHop: 10.0.0.1
+    neighbors: [192.0.2.2]
+`,
+			want: []string{"13:17: prefixes[0].neighbors[0]: 192.0.2.2 is not a configured neighbor"},
+		},
+		{
+			name: "withdraw threshold with withdrawOnDown",
+			body: `prefixes:
+  - ipAddress: 198.51.100.1/32
+    nextHop: 10.0.0.1
+    withdrawOnDown: true
+    degraded:
+      multiExitDescriminator: 100
+      withdrawThreshold: 2
+    readinessProbe:
+      exec:
+        command: "true"
+`,
+			want: []string{"13:7: prefixes[0].degraded.withdrawThreshold: requires withdrawOnDown false"},
+		},
+		{
+			name: "degraded without readiness probe",
+			body: `prefixes:
+  - ipAddress: 198.51.100.1/32
+    nextHop: 10.0.0.1
+    degraded:
+      multiExitDescriminator: 100
+`,
+			want: []string{"10:5: prefixes[0].degraded: requires readinessProbe"},
+		},
+		{
+			name: "maintenance without readiness probe",
+			body: `prefixes:
+  - ipAddress: 198.51.100.1/32
+    nextHop: 10.0.0.1
+    maintenance: /run/herald/maintenance
+`,
+			want: []string{"10:5: prefixes[0].maintenance: requires readinessProbe"},
+		},
+		{
+			name: "route condition",
+			body: `prefixes:
+  - ipAddress: 198.51.100.1/32
+    nextHop: 10.0.0.1
+    routeCondition:
+      prefixes: [0.0.0.0]
+    readinessProbe:
+      exec:
+        command: "true"
+`,
+			want: []string{"11:18: prefixes[0].routeCondition.prefixes[0]: must be a prefix in CIDR notation, got \"0.0.0.0\""},
+		},
+		{
+			name: "route condition without readiness probe",
+			body: `prefixes:
+  - ipAddress: 198.51.100.1/32
+    nextHop: 10.0.0.1
+    routeCondition:
+      prefixes: [0.0.0.0/0]
+`,
+			want: []string{"10:5: prefixes[0].routeCondition: requires readinessProbe"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.body)
+			_, err := New(path)
+			var errs validation.Errors
+			if err != nil && !errors.As(err, &errs) {
+				t.Fatalf("New: %v", err)
+			}
+			var got []string
+			for _, e := range errs {
+				got = append(got, strings.TrimPrefix(e.Error(), path+":"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	tests := []struct {
+		name               string
+		holdTime           time.Duration
+		wantKeepalive      time.Duration
+		degraded           *Degraded
+		wantWithdrawOnDown bool
+	}{
+		{name: "default hold time", wantKeepalive: 30 * time.Second, wantWithdrawOnDown: true},
+		{name: "keepalive rounded down", holdTime: 10 * time.Second, wantKeepalive: 3 * time.Second, wantWithdrawOnDown: true},
+		{name: "keepalive at least 1s", holdTime: 3 * time.Second, wantKeepalive: time.Second, wantWithdrawOnDown: true},
+		{name: "degraded", degraded: &Degraded{MultiExitDescriminator: 100}, wantKeepalive: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{
+				API:       ConfigAPI{ListenAddress: "127.0.0.1", ListenPort: 50051},
+				Speaker:   Speaker{ASN: 64600, RouterID: "10.0.0.1"},
+				Neighbors: []Neighbor{{Address: "192.0.2.1", ASN: 64601, HoldTime: tt.holdTime}},
+				Prefixes:  []Prefix{{IPAddress: "198.51.100.1/32", NextHop: "10.0.0.1", Degraded: tt.degraded}},
+			}
+			if tt.degraded != nil {
+				c.Prefixes[0].ReadinessProbe = &probe.Probe{ProbeExec: &probe.ProbeExec{Command: "true"}}
+			}
+			if err := c.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Neighbors[0].KeepaliveInterval; got != tt.wantKeepalive {
+				t.Errorf("keepaliveInterval = %s, want %s", got, tt.wantKeepalive)
+			}
+			if got := *c.Prefixes[0].WithdrawOnDown; got != tt.wantWithdrawOnDown {
+				t.Errorf("withdrawOnDown = %t, want %t", got, tt.wantWithdrawOnDown)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package exabgp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of exabgp-healthcheck for options that are not given.
+const (
+	DefaultInterval    = 5 * time.Second
+	DefaultTimeout     = 5 * time.Second
+	DefaultRise        = 3
+	DefaultFall        = 3
+	DefaultUpMED       = 100
+	DefaultDownMED     = 1000
+	DefaultDisabledMED = 500
+)
+
+// Healthcheck holds the options of an exabgp-healthcheck process.
+type Healthcheck struct {
+	Name                string
+	Command             string
+	IPs                 []string
+	Interval            time.Duration
+	Timeout             time.Duration
+	Rise                int
+	Fall                int
+	Communities         []string
+	LargeCommunities    []string
+	ExtendedCommunities []string
+	ASPath              []string
+	UpMED               uint32
+	DownMED             uint32
+	DisabledMED         uint32
+	LocalPref           uint32
+	WithdrawOnDown      bool
+	Maintenance         string
+	NextHop             string
+
+	// IPSetup adds the addresses to the loopback interface, DynamicIPSetup
+	// removes them when down.
+	IPSetup        bool
+	DynamicIPSetup bool
+
+	// Unmapped lists the options herald has no equivalent for, as given on
+	// the command line.
+	Unmapped []string
+}
+
+// ignoredOptions only affect how exabgp-healthcheck itself runs and have no
+// meaning for herald.
+var ignoredOptions = map[string]bool{
+	"debug": true, "silent": true, "syslog-facility": true, "no-syslog": true,
+	"sudo": true, "user": true, "group": true, "pid": true, "no-ack": true,
+	"d": true, "s": true, "sF": true, "p": true,
+}
+
+// flagOptions take no value.
+var flagOptions = map[string]bool{
+	"withdraw-on-down": true, "debug": true, "silent": true, "no-syslog": true,
+	"sudo": true, "no-ack": true, "ip-setup": true, "no-ip-setup": true,
+	"dynamic-ip-setup": true, "deaggregate-networks": true, "d": true, "s": true,
+}
+
+// ParseHealthcheck parses the arguments of an exabgp-healthcheck command
+// line, without the program name. Options read from a "--config" file are
+// applied first, so that the command line overrides them.
+func ParseHealthcheck(args []string) (*Healthcheck, error) {
+	h := &Healthcheck{
+		Interval:    DefaultInterval,
+		Timeout:     DefaultTimeout,
+		Rise:        DefaultRise,
+		Fall:        DefaultFall,
+		UpMED:       DefaultUpMED,
+		DownMED:     DefaultDownMED,
+		DisabledMED: DefaultDisabledMED,
+		IPSetup:     true,
+	}
+
+	options, err := splitOptions(args)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range options {
+		if o.name != "config" && o.name != "F" {
+			continue
+		}
+		fileOptions, err := readHealthcheckConfig(o.value)
+		if err != nil {
+			return nil, err
+		}
+		for _, fo := range fileOptions {
+			if err := h.set(fo); err != nil {
+				return nil, fmt.Errorf("%s: %w", o.value, err)
+			}
+		}
+	}
+	for _, o := range options {
+		if o.name == "config" || o.name == "F" {
+			continue
+		}
+		if err := h.set(o); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+type option struct {
+	name  string
+	value string
+	raw   string
+}
+
+// splitOptions splits args into "--name value", "--name=value" and "-n value"
+// options. Options not known to take no value consume the next argument
+// unless it looks like an option.
+func splitOptions(args []string) ([]option, error) {
+	var options []option
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return nil, fmt.Errorf("unexpected argument %q", arg)
+		}
+		o := option{name: strings.TrimLeft(arg, "-"), raw: arg}
+		if name, value, ok := strings.Cut(o.name, "="); ok {
+			o.name, o.value = name, value
+		} else if !flagOptions[o.name] && i+1 < len(args) && !isOption(args[i+1]) {
+			i++
+			o.value = args[i]
+			o.raw += " " + args[i]
+		}
+		options = append(options, o)
+	}
+	return options, nil
+}
+
+func isOption(arg string) bool {
+	if !strings.HasPrefix(arg, "-") {
+		return false
+	}
+	// Negative numbers and ranges are values, not options.
+	_, err := strconv.ParseFloat(arg, 64)
+	return err != nil
+}
+
+// readHealthcheckConfig reads an exabgp-healthcheck configuration file, made
+// of "name = value" or "name" lines.
+func readHealthcheckConfig(path string) ([]option, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read healthcheck configuration: %w", err)
+	}
+	var options []option
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, "=")
+		o := option{name: strings.TrimSpace(name), value: strings.Trim(strings.TrimSpace(value), `"'`)}
+		o.raw = "--" + o.name
+		if o.value != "" {
+			o.raw += " " + o.value
+		}
+		options = append(options, o)
+	}
+	return options, nil
+}
+
+func (h *Healthcheck) set(o option) error {
+	var err error
+	switch o.name {
+	case "name", "n":
+		h.Name = o.value
+	case "cmd", "command", "c":
+		h.Command = o.value
+	case "ip":
+		h.IPs = append(h.IPs, o.value)
+	case "interval", "i":
+		h.Interval, err = parseSeconds(o)
+	case "timeout", "t":
+		h.Timeout, err = parseSeconds(o)
+	case "rise":
+		h.Rise, err = parseCount(o)
+	case "fall":
+		h.Fall, err = parseCount(o)
+	case "community":
+		h.Communities = append(h.Communities, strings.Fields(o.value)...)
+	case "large-community":
+		h.LargeCommunities = append(h.LargeCommunities, strings.Fields(o.value)...)
+	case "extended-community":
+		h.ExtendedCommunities = append(h.ExtendedCommunities, strings.Fields(o.value)...)
+	case "as-path":
+		h.ASPath = strings.Fields(strings.Trim(o.value, "[]"))
+	case "med", "up-med":
+		h.UpMED, err = parseMED(o)
+	case "down-med":
+		h.DownMED, err = parseMED(o)
+	case "disabled-med":
+		h.DisabledMED, err = parseMED(o)
+	case "local-preference":
+		h.LocalPref, err = parseUint32(o)
+	case "withdraw-on-down":
+		h.WithdrawOnDown = true
+	case "ip-setup":
+		h.IPSetup = true
+	case "no-ip-setup":
+		h.IPSetup = false
+	case "dynamic-ip-setup":
+		h.DynamicIPSetup = true
+	case "disable", "maintenance":
+		h.Maintenance = o.value
+	case "next-hop", "N":
+		h.NextHop = o.value
+	default:
+		if !ignoredOptions[o.name] {
+			h.Unmapped = append(h.Unmapped, o.raw)
+		}
+	}
+	return err
+}
+
+func parseSeconds(o option) (time.Duration, error) {
+	v, err := strconv.ParseFloat(o.value, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("--%s: invalid number of seconds %q", o.name, o.value)
+	}
+	return time.Duration(v * float64(time.Second)), nil
+}
+
+func parseCount(o option) (int, error) {
+	v, err := strconv.Atoi(o.value)
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("--%s: invalid count %q", o.name, o.value)
+	}
+	return v, nil
+}
+
+func parseMED(o option) (uint32, error) {
+	v, err := strconv.ParseUint(o.value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("--%s: invalid MED %q", o.name, o.value)
+	}
+	return uint32(v), nil
+}
+
+func parseUint32(o option) (uint32, error) {
+	v, err := strconv.ParseUint(o.value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("--%s: invalid value %q", o.name, o.value)
+	}
+	return uint32(v), nil
+}
+
+// healthcheckArgs returns the arguments following the healthcheck program in
+// a process "run" command line, and false when the process does not run
+// exabgp-healthcheck.
+func healthcheckArgs(run []string) ([]string, bool) {
+	for i, arg := range run {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		base := filepath.Base(arg)
+		if base == "healthcheck" || strings.HasPrefix(base, "exabgp-healthcheck") || strings.HasPrefix(base, "healthcheck.") {
+			return run[i+1:], true
+		}
+	}
+	return nil, false
+}
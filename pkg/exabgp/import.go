@@ -0,0 +1,702 @@
+// Package exabgp converts ExaBGP configurations and exabgp-healthcheck
+// processes into herald configurations.
+package exabgp
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultAPIListenAddress and DefaultAPIListenPort are used for the api
+// section, which has no ExaBGP equivalent.
+const (
+	DefaultAPIListenAddress = "127.0.0.1"
+	DefaultAPIListenPort    = 50051
+)
+
+// DefaultListenPort is the BGP port herald listens on for passive neighbors.
+const DefaultListenPort = 179
+
+// alwaysUp is the probe command used for routes ExaBGP announces without a
+// health check.
+const alwaysUp = "/bin/true"
+
+// loopback is the interface exabgp-healthcheck adds the addresses to.
+const loopback = "lo"
+
+// Result is a herald configuration imported from ExaBGP along with the
+// settings that could not be mapped.
+type Result struct {
+	Config   *Config
+	Warnings []string
+}
+
+// WriteYAML writes the imported configuration to w, preceded by the warnings
+// as comments.
+func (r *Result) WriteYAML(w io.Writer, source string) error {
+	fmt.Fprintf(w, "# Imported from %s by \"herald import exabgp\".\n", source)
+	if len(r.Warnings) > 0 {
+		fmt.Fprintln(w, "# The following ExaBGP settings could not be mapped and need review:")
+		for _, warning := range r.Warnings {
+			fmt.Fprintf(w, "#   - %s\n", warning)
+		}
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(r.Config); err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	return enc.Close()
+}
+
+// Import converts the ExaBGP configuration data read from file, which may be
+// empty, and the additional exabgp-healthcheck command lines healthchecks
+// (arguments without the program name) into a herald configuration.
+func Import(file string, data []byte, healthchecks [][]string) (*Result, error) {
+	statements, err := Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	im := &importer{
+		file:      file,
+		result:    &Result{Config: &Config{API: API{ListenAddress: DefaultAPIListenAddress, ListenPort: DefaultAPIListenPort}}},
+		templates: map[string]*Statement{},
+		processes: map[string]*Statement{},
+		announced: map[string][]string{},
+	}
+
+	// Templates and processes may be defined after the neighbors using them.
+	for _, s := range statements {
+		switch s.Keyword() {
+		case "template":
+			for _, t := range s.Children {
+				if t.Keyword() != "neighbor" || t.Arg(1) == "" {
+					im.warnf(t, "template %q is not supported", t.String())
+					continue
+				}
+				im.templates[t.Arg(1)] = t
+			}
+		case "process":
+			im.addProcess(s)
+		}
+	}
+	for _, s := range statements {
+		switch s.Keyword() {
+		case "template", "process":
+		case "neighbor":
+			im.neighbor(s, nil)
+		case "group":
+			im.group(s)
+		default:
+			im.warnf(s, "%q is not supported", s.String())
+		}
+	}
+
+	for _, name := range im.processOrder {
+		if err := im.process(name); err != nil {
+			return nil, err
+		}
+	}
+	for i, args := range healthchecks {
+		h, err := ParseHealthcheck(args)
+		if err != nil {
+			return nil, fmt.Errorf("healthcheck %q: %w", strings.Join(args, " "), err)
+		}
+		if h.Name == "" {
+			h.Name = "healthcheck"
+			if len(healthchecks) > 1 {
+				h.Name = fmt.Sprintf("healthcheck-%d", i+1)
+			}
+		}
+		if err := im.healthcheck(h, "healthcheck "+h.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	im.finish()
+	return im.result, nil
+}
+
+type importer struct {
+	file   string
+	result *Result
+
+	templates    map[string]*Statement
+	processes    map[string]*Statement
+	processOrder []string
+	// announced lists the neighbors announcing each static route.
+	announced map[string][]string
+}
+
+func (im *importer) warnf(s *Statement, format string, args ...any) {
+	im.result.warn(fmt.Sprintf("%s:%d: ", im.file, s.Line) + fmt.Sprintf(format, args...))
+}
+
+// warn records a warning once, as statements inherited from templates and
+// groups are seen for every neighbor.
+func (r *Result) warn(warning string) {
+	if !contains(r.Warnings, warning) {
+		r.Warnings = append(r.Warnings, warning)
+	}
+}
+
+func (im *importer) addProcess(s *Statement) {
+	name := s.Arg(1)
+	if name == "" || !s.Block {
+		im.warnf(s, "process %q is not supported", s.String())
+		return
+	}
+	if _, ok := im.processes[name]; !ok {
+		im.processOrder = append(im.processOrder, name)
+	}
+	im.processes[name] = s
+}
+
+// group imports an ExaBGP 3 group, whose settings apply to the neighbors it
+// contains.
+func (im *importer) group(g *Statement) {
+	var common, neighbors []*Statement
+	for _, s := range g.Children {
+		if s.Keyword() == "neighbor" {
+			neighbors = append(neighbors, s)
+		} else {
+			common = append(common, s)
+		}
+	}
+	for _, s := range neighbors {
+		im.neighbor(s, common)
+	}
+}
+
+func (im *importer) neighbor(s *Statement, inherited []*Statement) {
+	c := im.result.Config
+	n := &Neighbor{Address: s.Arg(1)}
+	if net.ParseIP(n.Address) == nil {
+		im.warnf(s, "neighbor %q does not have an IP address, skipped", n.Address)
+		return
+	}
+
+	statements := append([]*Statement{}, inherited...)
+	for _, st := range s.Children {
+		if st.Keyword() != "inherit" {
+			continue
+		}
+		for _, name := range listValues(st.Words[1:]) {
+			if t, ok := im.templates[name]; ok {
+				statements = append(statements, t.Children...)
+			} else {
+				im.warnf(st, "template %q is not defined", name)
+			}
+		}
+	}
+	statements = append(statements, s.Children...)
+
+	routes := map[string]bool{}
+	for _, st := range statements {
+		switch st.Keyword() {
+		case "inherit", "description", "host-name", "domain-name", "group-updates",
+			"auto-flush", "adj-rib-in", "adj-rib-out", "manual-eor":
+		case "router-id":
+			if c.Speaker.RouterID != "" && c.Speaker.RouterID != st.Arg(1) {
+				im.warnf(st, "router-id %s differs from %s used by other neighbors, herald has a single router ID", st.Arg(1), c.Speaker.RouterID)
+				continue
+			}
+			c.Speaker.RouterID = st.Arg(1)
+		case "local-as":
+			asn, ok := im.asn(st)
+			if !ok {
+				continue
+			}
+			if c.Speaker.ASN != 0 && c.Speaker.ASN != asn {
+				im.warnf(st, "local-as %d differs from %d used by other neighbors, herald has a single local AS", asn, c.Speaker.ASN)
+				continue
+			}
+			c.Speaker.ASN = asn
+		case "peer-as":
+			if asn, ok := im.asn(st); ok {
+				n.ASN = asn
+			}
+		case "local-address":
+			n.LocalAddress = st.Arg(1)
+		case "md5-password", "md5":
+			n.Password = st.Arg(1)
+		case "multihop", "outgoing-ttl":
+			if st.Arg(1) == "" {
+				n.EbgpMultihopEnabled = true
+				continue
+			}
+			ttl, ok := im.ttl(st)
+			if ok && ttl > 1 {
+				n.EbgpMultihopEnabled = true
+				n.EbgpMultihopTTL = ttl
+			}
+		case "incoming-ttl":
+			if ttl, ok := im.ttl(st); ok {
+				n.TTLSecurityEnabled = true
+				n.TTLMin = ttl
+			}
+		case "hold-time":
+			seconds, err := strconv.ParseUint(st.Arg(1), 10, 16)
+			if err != nil {
+				im.warnf(st, "invalid hold-time %q", st.Arg(1))
+				continue
+			}
+			n.HoldTime = time.Duration(seconds) * time.Second
+		case "passive":
+			if st.Arg(1) == "" || st.Arg(1) == "true" || st.Arg(1) == "enable" {
+				n.Passive = true
+				c.Speaker.ListenPort = DefaultListenPort
+			}
+		case "family":
+			n.Families = im.families(st)
+		case "capability":
+			im.capability(st)
+		case "api":
+			for _, a := range st.Children {
+				if a.Keyword() == "processes" {
+					n.processes = append(n.processes, listValues(a.Words[1:])...)
+				}
+			}
+		case "process":
+			// ExaBGP 3 defines processes inside neighbors.
+			if st.Block {
+				im.addProcess(st)
+			}
+			n.processes = append(n.processes, st.Arg(1))
+		case "static":
+			for _, r := range st.Children {
+				if r.Keyword() != "route" {
+					im.warnf(r, "%q is not supported", r.String())
+					continue
+				}
+				im.route(r, r.Words[1:], routes)
+			}
+		case "announce":
+			for _, family := range st.Children {
+				for _, r := range family.Children {
+					if r.Keyword() != "unicast" {
+						im.warnf(r, "%s %s announcements are not supported", family.Keyword(), r.Keyword())
+						continue
+					}
+					im.route(r, r.Words[1:], routes)
+				}
+			}
+		default:
+			im.warnf(st, "%q is not supported", st.String())
+		}
+	}
+
+	for _, existing := range c.Neighbors {
+		if existing.Address == n.Address {
+			im.warnf(s, "neighbor %s is defined more than once, skipped", n.Address)
+			return
+		}
+	}
+	c.Neighbors = append(c.Neighbors, n)
+	for ip := range routes {
+		im.announced[ip] = append(im.announced[ip], n.Address)
+	}
+}
+
+func (im *importer) ttl(s *Statement) (uint8, bool) {
+	ttl, err := strconv.ParseUint(s.Arg(1), 10, 8)
+	if err != nil || ttl == 0 {
+		im.warnf(s, "invalid %s %q", s.Keyword(), s.Arg(1))
+		return 0, false
+	}
+	return uint8(ttl), true
+}
+
+func (im *importer) asn(s *Statement) (uint32, bool) {
+	asn, err := strconv.ParseUint(s.Arg(1), 10, 32)
+	if err != nil {
+		im.warnf(s, "invalid %s %q", s.Keyword(), s.Arg(1))
+		return 0, false
+	}
+	return uint32(asn), true
+}
+
+// families returns the herald families of an ExaBGP family block.
+func (im *importer) families(s *Statement) []string {
+	statements := s.Children
+	if !s.Block {
+		statements = []*Statement{{Words: s.Words[1:], Line: s.Line}}
+	}
+	var families []string
+	for _, f := range statements {
+		switch f.String() {
+		case "ipv4 unicast", "ipv6 unicast":
+			families = append(families, f.Arg(0)+"-unicast")
+		case "ipv4 all", "ipv6 all":
+			families = append(families, f.Arg(0)+"-unicast")
+			im.warnf(f, "family %s is imported as %s-unicast", f.String(), f.Arg(0))
+		default:
+			im.warnf(f, "family %s is not supported", f.String())
+		}
+	}
+	return families
+}
+
+func (im *importer) capability(s *Statement) {
+	speaker := &im.result.Config.Speaker
+	for _, cap := range s.Children {
+		switch cap.Keyword() {
+		case "route-refresh", "asn4":
+		case "graceful-restart":
+			if cap.Arg(1) == "disable" {
+				continue
+			}
+			speaker.GracefulRestartEnabled = true
+			if cap.Arg(1) != "" && cap.Arg(1) != "enable" {
+				t, err := strconv.ParseUint(cap.Arg(1), 10, 32)
+				if err != nil {
+					im.warnf(cap, "invalid graceful-restart time %q", cap.Arg(1))
+					continue
+				}
+				speaker.GracefulRestartRestartTime = uint32(t)
+			}
+		default:
+			if cap.Arg(1) != "disable" {
+				im.warnf(cap, "capability %q is not supported", cap.String())
+			}
+		}
+	}
+}
+
+// route imports a static route, whose words are the prefix followed by its
+// attributes.
+func (im *importer) route(s *Statement, words []string, seen map[string]bool) {
+	if len(words) == 0 {
+		im.warnf(s, "route without prefix")
+		return
+	}
+	p := &Prefix{
+		IPAddress:      normalizePrefix(words[0]),
+		WithdrawOnDown: true,
+		ReadinessProbe: &Probe{Exec: &ProbeExec{Command: alwaysUp}},
+	}
+	for i := 1; i < len(words); {
+		key := words[i]
+		values, next := attributeValues(words, i+1)
+		i = next
+		switch key {
+		case "next-hop":
+			if len(values) > 0 && values[0] != "self" {
+				p.NextHop = values[0]
+			}
+		case "community":
+			p.Communities = values
+		case "large-community":
+			p.LargeCommunities = values
+		case "extended-community":
+			p.ExtendedCommunities = values
+		case "med":
+			med, err := strconv.ParseUint(strings.Join(values, ""), 10, 32)
+			if err != nil {
+				im.warnf(s, "route %s: invalid med %q", words[0], strings.Join(values, " "))
+				continue
+			}
+			p.MultiExitDescriminator = uint32(med)
+		case "local-preference":
+			localPref, err := strconv.ParseUint(strings.Join(values, ""), 10, 32)
+			if err != nil {
+				im.warnf(s, "route %s: invalid local-preference %q", words[0], strings.Join(values, " "))
+				continue
+			}
+			p.LocalPreference = uint32(localPref)
+		case "origin":
+			switch origin := strings.ToLower(strings.Join(values, "")); origin {
+			case "igp", "egp", "incomplete":
+				p.Origin = origin
+			default:
+				im.warnf(s, "route %s: invalid origin %q", words[0], origin)
+			}
+		case "as-path":
+			asPath, ok := parseASPath(values)
+			if !ok {
+				im.warnf(s, "route %s: invalid as-path %q", words[0], strings.Join(values, " "))
+				continue
+			}
+			p.AsPathPrepend = asPath
+		default:
+			im.warnf(s, "route %s: attribute %s %s is not supported", words[0], key, strings.Join(values, " "))
+		}
+	}
+
+	seen[p.IPAddress] = true
+	for _, existing := range im.result.Config.Prefixes {
+		if existing.IPAddress != p.IPAddress {
+			continue
+		}
+		if !reflect.DeepEqual(existing, p) {
+			im.warnf(s, "route %s is announced with different attributes to other neighbors, only the first one is kept", words[0])
+		}
+		return
+	}
+	im.warnf(s, "route %s is announced unconditionally by ExaBGP, imported with an always successful readiness probe", words[0])
+	im.result.Config.Prefixes = append(im.result.Config.Prefixes, p)
+}
+
+func (im *importer) process(name string) error {
+	s := im.processes[name]
+	var run []string
+	for _, st := range s.Children {
+		if st.Keyword() == "run" {
+			run = st.Words[1:]
+		}
+	}
+	if len(run) == 1 {
+		args, err := SplitArgs(run[0])
+		if err != nil {
+			im.warnf(s, "process %s: %v", name, err)
+			return nil
+		}
+		run = args
+	}
+
+	args, ok := healthcheckArgs(run)
+	if !ok {
+		im.warnf(s, "process %s runs %q, which is not exabgp-healthcheck, skipped", name, strings.Join(run, " "))
+		return nil
+	}
+	h, err := ParseHealthcheck(args)
+	if err != nil {
+		im.warnf(s, "process %s: %v, skipped", name, err)
+		return nil
+	}
+	if h.Name == "" {
+		h.Name = name
+	}
+
+	users := 0
+	for _, n := range im.result.Config.Neighbors {
+		for _, p := range n.processes {
+			if p == name {
+				users++
+				break
+			}
+		}
+	}
+	switch {
+	case users == 0:
+		im.warnf(s, "process %s is not used by any neighbor, herald announces its prefixes to every neighbor", name)
+	case users < len(im.result.Config.Neighbors):
+		im.warnf(s, "process %s is only used by some neighbors, herald announces its prefixes to every neighbor", name)
+	}
+
+	return im.healthcheck(h, fmt.Sprintf("%s:%d: process %s", im.file, s.Line, name))
+}
+
+// healthcheck imports the prefixes announced by an exabgp-healthcheck
+// process. Its check is shared when it announces several prefixes. It fails
+// without --cmd when options could not be mapped, as one of them may hold
+// the command.
+func (im *importer) healthcheck(h *Healthcheck, origin string) error {
+	c := im.result.Config
+	warnf := func(format string, args ...any) {
+		im.result.warn(origin + ": " + fmt.Sprintf(format, args...))
+	}
+
+	probe := &Probe{
+		PeriodSeconds:    h.Interval,
+		TimeoutSeconds:   h.Timeout,
+		FailureThreshold: h.Fall,
+		SuccessThreshold: h.Rise,
+		Exec:             &ProbeExec{Command: "/bin/sh", Args: []string{"-c", h.Command}},
+	}
+	if h.Command == "" {
+		if len(h.Unmapped) > 0 {
+			return fmt.Errorf("%s: no --cmd and unsupported options %s, the health check cannot be imported", origin, strings.Join(h.Unmapped, ", "))
+		}
+		probe.Exec = &ProbeExec{Command: alwaysUp}
+		warnf("no --cmd, the service is considered always up")
+	}
+	for _, o := range h.Unmapped {
+		warnf("%s is not supported", o)
+	}
+	// Without --withdraw-on-down, ExaBGP keeps announcing a failed prefix
+	// with the down MED, which is what degraded does without a withdraw
+	// threshold, as withdrawOnDown then defaults to false. --rise and --fall
+	// gate readiness like the probe thresholds do.
+	var degraded *Degraded
+	switch {
+	case h.WithdrawOnDown:
+	case h.DownMED == 0:
+		warnf("without --withdraw-on-down ExaBGP keeps announcing with MED 0 when down, herald withdraws instead")
+	default:
+		degraded = &Degraded{MultiExitDescriminator: h.DownMED}
+	}
+	if !h.WithdrawOnDown && h.Maintenance != "" {
+		warnf("without --withdraw-on-down ExaBGP keeps announcing with MED %d when disabled, herald withdraws instead", h.DisabledMED)
+	}
+	asPath, ok := parseASPath(h.ASPath)
+	if !ok {
+		warnf("invalid --as-path %q", strings.Join(h.ASPath, " "))
+	}
+	nextHop := h.NextHop
+	if nextHop == "self" {
+		nextHop = ""
+	}
+	if len(h.IPs) == 0 {
+		warnf("no --ip, exabgp-healthcheck announces the addresses of the loopback interface, add the prefixes manually")
+		return nil
+	}
+	// exabgp-healthcheck adds missing addresses to the loopback interface
+	// unless --no-ip-setup.
+	var iface string
+	if h.IPSetup {
+		iface = loopback
+	}
+
+	readiness := probe
+	if len(h.IPs) > 1 {
+		name := h.Name
+		for i := 2; c.Checks[name] != nil; i++ {
+			name = fmt.Sprintf("%s-%d", h.Name, i)
+		}
+		if c.Checks == nil {
+			c.Checks = map[string]*Probe{}
+		}
+		c.Checks[name] = probe
+		readiness = &Probe{Check: name}
+	}
+
+next:
+	for _, ip := range h.IPs {
+		p := &Prefix{
+			IPAddress:              normalizePrefix(ip),
+			Name:                   h.Name,
+			Communities:            h.Communities,
+			LargeCommunities:       h.LargeCommunities,
+			ExtendedCommunities:    h.ExtendedCommunities,
+			Interface:              iface,
+			KeepAddress:            iface != "" && !h.DynamicIPSetup,
+			NextHop:                nextHop,
+			MultiExitDescriminator: h.UpMED,
+			LocalPreference:        h.LocalPref,
+			AsPathPrepend:          asPath,
+			WithdrawOnDown:         degraded == nil,
+			Maintenance:            h.Maintenance,
+			ReadinessProbe:         readiness,
+			Degraded:               degraded,
+		}
+		for _, existing := range c.Prefixes {
+			if existing.IPAddress == p.IPAddress {
+				warnf("prefix %s is already imported, skipped", p.IPAddress)
+				continue next
+			}
+		}
+		c.Prefixes = append(c.Prefixes, p)
+	}
+	return nil
+}
+
+// finish fills the settings derived from several sections and reports those
+// still missing.
+func (im *importer) finish() {
+	c := im.result.Config
+	warnf := func(format string, args ...any) {
+		im.result.warn(fmt.Sprintf(format, args...))
+	}
+
+	var localAddresses []string
+	for _, n := range c.Neighbors {
+		if n.LocalAddress != "" && !contains(localAddresses, n.LocalAddress) {
+			localAddresses = append(localAddresses, n.LocalAddress)
+		}
+		if n.ASN == 0 {
+			warnf("neighbor %s has no peer-as, set asn", n.Address)
+		}
+	}
+
+	if c.Speaker.RouterID == "" && len(localAddresses) == 1 {
+		c.Speaker.RouterID = localAddresses[0]
+	}
+	if c.Speaker.RouterID == "" {
+		warnf("no router-id, set speaker.routerId")
+	}
+	if c.Speaker.ASN == 0 {
+		warnf("no local-as, set speaker.asn")
+	}
+
+	for _, p := range c.Prefixes {
+		if neighbors, ok := im.announced[p.IPAddress]; ok && len(neighbors) < len(c.Neighbors) {
+			sort.Strings(neighbors)
+			p.Neighbors = neighbors
+		}
+	}
+}
+
+// attributeValues returns the values of the route attribute starting at
+// words[i], either a single word or a bracketed list, and the index of the
+// next attribute.
+func attributeValues(words []string, i int) ([]string, int) {
+	if i >= len(words) {
+		return nil, i
+	}
+	if words[i] != "[" {
+		return []string{words[i]}, i + 1
+	}
+	var values []string
+	for i++; i < len(words) && words[i] != "]"; i++ {
+		values = append(values, words[i])
+	}
+	return values, i + 1
+}
+
+// listValues returns words without the brackets of an ExaBGP list.
+func listValues(words []string) []string {
+	var values []string
+	for _, w := range words {
+		if w != "[" && w != "]" {
+			values = append(values, w)
+		}
+	}
+	return values
+}
+
+func parseASPath(values []string) ([]uint32, bool) {
+	var asPath []uint32
+	for _, v := range values {
+		asn, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, false
+		}
+		asPath = append(asPath, uint32(asn))
+	}
+	return asPath, true
+}
+
+// normalizePrefix adds a host mask to a bare address.
+func normalizePrefix(s string) string {
+	if strings.Contains(s, "/") {
+		return s
+	}
+	ip := net.ParseIP(s)
+	switch {
+	case ip == nil:
+		return s
+	case ip.To4() != nil:
+		return s + "/32"
+	default:
+		return s + "/128"
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package exabgp
+
+import "time"
+
+// The types below mirror the subset of the herald configuration the importer
+// produces, omitting unset fields so that the output stays readable.
+
+// Config is an imported herald configuration.
+type Config struct {
+	Speaker   Speaker           `yaml:"speaker"`
+	API       API               `yaml:"api"`
+	Neighbors []*Neighbor       `yaml:"neighbors"`
+	Checks    map[string]*Probe `yaml:"checks,omitempty"`
+	Prefixes  []*Prefix         `yaml:"prefixes"`
+}
+
+type Speaker struct {
+	ASN                        uint32 `yaml:"asn"`
+	RouterID                   string `yaml:"routerId"`
+	GracefulRestartEnabled     bool   `yaml:"gracefulRestartEnabled,omitempty"`
+	GracefulRestartRestartTime uint32 `yaml:"gracefulRestartRestartTime,omitempty"`
+	ListenPort                 int32  `yaml:"listenPort,omitempty"`
+}
+
+type API struct {
+	ListenAddress string `yaml:"listenAddress"`
+	ListenPort    int    `yaml:"listenPort"`
+}
+
+type Neighbor struct {
+	Address             string        `yaml:"address"`
+	ASN                 uint32        `yaml:"asn"`
+	EbgpMultihopEnabled bool          `yaml:"ebgpMultihopEnabled,omitempty"`
+	EbgpMultihopTTL     uint8         `yaml:"ebgpMultihopTtl,omitempty"`
+	TTLSecurityEnabled  bool          `yaml:"ttlSecurityEnabled,omitempty"`
+	TTLMin              uint8         `yaml:"ttlMin,omitempty"`
+	LocalAddress        string        `yaml:"localAddress,omitempty"`
+	Passive             bool          `yaml:"passive,omitempty"`
+	HoldTime            time.Duration `yaml:"holdTime,omitempty"`
+	Families            []string      `yaml:"families,omitempty,flow"`
+	Password            string        `yaml:"password,omitempty"`
+
+	processes []string
+}
+
+type Prefix struct {
+	IPAddress              string    `yaml:"ipAddress"`
+	Name                   string    `yaml:"name,omitempty"`
+	Communities            []string  `yaml:"communities,omitempty"`
+	LargeCommunities       []string  `yaml:"largeCommunities,omitempty"`
+	ExtendedCommunities    []string  `yaml:"extendedCommunities,omitempty"`
+	Interface              string    `yaml:"interface,omitempty"`
+	KeepAddress            bool      `yaml:"keepAddress,omitempty"`
+	NextHop                string    `yaml:"nextHop,omitempty"`
+	Origin                 string    `yaml:"origin,omitempty"`
+	MultiExitDescriminator uint32    `yaml:"multiExitDescriminator,omitempty"`
+	LocalPreference        uint32    `yaml:"localPreference,omitempty"`
+	AsPathPrepend          []uint32  `yaml:"asPathPrepend,omitempty,flow"`
+	Neighbors              []string  `yaml:"neighbors,omitempty,flow"`
+	WithdrawOnDown         bool      `yaml:"withdrawOnDown,omitempty"`
+	Maintenance            string    `yaml:"maintenance,omitempty"`
+	ReadinessProbe         *Probe    `yaml:"readinessProbe"`
+	Degraded               *Degraded `yaml:"degraded,omitempty"`
+}
+
+type Degraded struct {
+	MultiExitDescriminator uint32 `yaml:"multiExitDescriminator,omitempty"`
+}
+
+type Probe struct {
+	Check            string        `yaml:"check,omitempty"`
+	PeriodSeconds    time.Duration `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds   time.Duration `yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold int           `yaml:"failureThreshold,omitempty"`
+	SuccessThreshold int           `yaml:"successThreshold,omitempty"`
+	Exec             *ProbeExec    `yaml:"exec,omitempty"`
+}
+
+type ProbeExec struct {
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args,omitempty"`
+}
@@ -0,0 +1,198 @@
+package exabgp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Statement is an ExaBGP configuration statement, either a simple one
+// terminated by ";" or a block with children.
+type Statement struct {
+	Words    []string
+	Children []*Statement
+	Block    bool
+	Line     int
+}
+
+// Keyword returns the first word of the statement.
+func (s *Statement) Keyword() string {
+	if len(s.Words) == 0 {
+		return ""
+	}
+	return s.Words[0]
+}
+
+// Arg returns the word at index i, or "" when missing.
+func (s *Statement) Arg(i int) string {
+	if i >= len(s.Words) {
+		return ""
+	}
+	return s.Words[i]
+}
+
+func (s *Statement) String() string {
+	return strings.Join(s.Words, " ")
+}
+
+type token struct {
+	value  string
+	quoted bool
+	line   int
+}
+
+// Parse parses an ExaBGP configuration file into its top-level statements.
+func Parse(data string) ([]*Statement, error) {
+	tokens, err := tokenize(data)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	statements, err := p.statements()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("line %d: unexpected %q", p.tokens[p.pos].line, p.tokens[p.pos].value)
+	}
+	return statements, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) statements() ([]*Statement, error) {
+	var statements []*Statement
+	for p.pos < len(p.tokens) {
+		t := p.tokens[p.pos]
+		if !t.quoted && t.value == "}" {
+			return statements, nil
+		}
+
+		s := &Statement{Line: t.line}
+		for {
+			if p.pos >= len(p.tokens) {
+				return nil, fmt.Errorf("line %d: missing ';' after %q", s.Line, s.String())
+			}
+			t := p.tokens[p.pos]
+			p.pos++
+			if t.quoted {
+				s.Words = append(s.Words, t.value)
+				continue
+			}
+			switch t.value {
+			case ";":
+			case "{":
+				children, err := p.statements()
+				if err != nil {
+					return nil, err
+				}
+				if p.pos >= len(p.tokens) {
+					return nil, fmt.Errorf("line %d: missing '}' for %q", s.Line, s.String())
+				}
+				p.pos++
+				s.Block = true
+				s.Children = children
+			case "}":
+				return nil, fmt.Errorf("line %d: unexpected '}'", t.line)
+			default:
+				s.Words = append(s.Words, t.value)
+				continue
+			}
+			break
+		}
+		if len(s.Words) > 0 || s.Block {
+			statements = append(statements, s)
+		}
+	}
+	return statements, nil
+}
+
+// tokenize splits data into words, quoted strings and the punctuation
+// "{", "}" and ";". Comments start with "#". Lists in brackets are kept as
+// separate "[" and "]" words.
+func tokenize(data string) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '{' || c == '}' || c == ';' || c == '[' || c == ']':
+			tokens = append(tokens, token{value: string(c), line: line})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(data[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			value := data[i+1 : i+1+end]
+			tokens = append(tokens, token{value: value, quoted: true, line: line})
+			line += strings.Count(value, "\n")
+			i += end + 2
+		default:
+			start := i
+			for i < len(data) && !strings.ContainsRune(" \t\r\n{};[]#\"'", rune(data[i])) {
+				i++
+			}
+			tokens = append(tokens, token{value: data[start:i], line: line})
+		}
+	}
+	return tokens, nil
+}
+
+// SplitArgs splits a command line like a POSIX shell would for simple
+// quoting: single quotes, double quotes and backslash escapes.
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	var b strings.Builder
+	inArg := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			switch {
+			case c == quote:
+				quote = 0
+			case c == '\\' && quote == '"' && i+1 < len(s):
+				i++
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+		default:
+			b.WriteByte(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args, nil
+}
@@ -6,6 +6,8 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/ahmet2mir/herald/pkg/validation"
 )
 
 // Driver represents the logging driver type
@@ -29,10 +31,14 @@ const (
 
 // Config holds the logging configuration
 type Config struct {
+	// Log destination: syslog, journald, file, windows or none. Defaults to file.
 	Driver Driver `yaml:"driver"`
+	// Log format: json or text. Defaults to json.
 	Format Format `yaml:"format"`
-	Level  string `yaml:"level"`
-	File   string `yaml:"file"` // Used when driver is "file"
+	// Minimum level: debug, info, warn or error. Defaults to info.
+	Level string `yaml:"level"`
+	// Log file path, used when driver is "file". Defaults to herald.log.
+	File string `yaml:"file"`
 }
 
 // DefaultConfig returns the default logging configuration
@@ -122,3 +128,35 @@ type noopWriter struct{}
 func (nw *noopWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
+
+// Validate fills unset fields from DefaultConfig and records invalid values
+// of the logging configuration found at path.
+func (c *Config) Validate(path string, errs *validation.Errors) {
+	defaults := DefaultConfig()
+	if c.Driver == "" {
+		c.Driver = defaults.Driver
+	}
+	if c.Format == "" {
+		c.Format = defaults.Format
+	}
+	if c.Level == "" {
+		c.Level = defaults.Level
+	}
+	if c.Driver == DriverFile && c.File == "" {
+		c.File = defaults.File
+	}
+
+	switch c.Driver {
+	case DriverSyslog, DriverJournald, DriverFile, DriverWindows, DriverNone:
+	default:
+		errs.Addf(validation.Field(path, "driver"), "unsupported log driver %q", c.Driver)
+	}
+	switch c.Format {
+	case FormatJSON, FormatText:
+	default:
+		errs.Addf(validation.Field(path, "format"), "unsupported log format %q", c.Format)
+	}
+	if _, err := zapcore.ParseLevel(c.Level); err != nil {
+		errs.Addf(validation.Field(path, "level"), "invalid log level %q", c.Level)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	api "github.com/osrg/gobgp/v3/api"
 	"github.com/osrg/gobgp/v3/pkg/server"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
 type GoBGPCollector struct {
@@ -60,6 +61,10 @@ func (c *GoBGPCollector) collectPeerMetrics() {
 		}
 
 		peerAddr := peer.Conf.NeighborAddress
+		// Dynamic neighbors only have their address in their state.
+		if peerAddr == "" && peer.State != nil {
+			peerAddr = peer.State.NeighborAddress
+		}
 		peerASN := strconv.FormatUint(uint64(peer.Conf.PeerAsn), 10)
 
 		if peer.State != nil {
@@ -88,6 +93,19 @@ func (c *GoBGPCollector) collectPeerMetrics() {
 				}
 			}
 		}
+
+		for _, afiSafi := range peer.AfiSafis {
+			state := afiSafi.GetState()
+			if state == nil {
+				continue
+			}
+			for name, family := range routeFamilies {
+				if proto.Equal(family, state.Family) {
+					BGPPeerReceivedRoutes.WithLabelValues(peerAddr, peerASN, name).Set(float64(state.Received))
+					BGPPeerAcceptedRoutes.WithLabelValues(peerAddr, peerASN, name).Set(float64(state.Accepted))
+				}
+			}
+		}
 	})
 
 	if err != nil {
@@ -95,20 +113,27 @@ func (c *GoBGPCollector) collectPeerMetrics() {
 	}
 }
 
-func (c *GoBGPCollector) collectRouteMetrics() {
-	family := &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST}
+// routeFamilies are the address families routes are counted for, by label.
+var routeFamilies = map[string]*api.Family{
+	"ipv4-unicast": {Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST},
+	"ipv6-unicast": {Afi: api.Family_AFI_IP6, Safi: api.Family_SAFI_UNICAST},
+}
 
-	count := 0
-	err := c.server.ListPath(c.ctx, &api.ListPathRequest{
-		Family: family,
-	}, func(destination *api.Destination) {
-		count++
-	})
+func (c *GoBGPCollector) collectRouteMetrics() {
+	for name, family := range routeFamilies {
+		count := 0
+		err := c.server.ListPath(c.ctx, &api.ListPathRequest{
+			TableType: api.TableType_GLOBAL,
+			Family:    family,
+		}, func(destination *api.Destination) {
+			count++
+		})
+
+		if err != nil {
+			zap.S().Debug("Failed to collect BGP route metrics", err)
+			continue
+		}
 
-	if err != nil {
-		zap.S().Debug("Failed to collect BGP route metrics", err)
-		return
+		BGPRouteCount.WithLabelValues("global", name).Set(float64(count))
 	}
-
-	BGPRouteCount.WithLabelValues("global").Set(float64(count))
 }
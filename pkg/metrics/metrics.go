@@ -14,6 +14,62 @@ var (
 		[]string{"prefix", "name"},
 	)
 
+	PrefixDegraded = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "herald_prefix_degraded",
+			Help: "Prefix degraded status (1=announced with degraded attributes, 0=not degraded)",
+		},
+		[]string{"prefix", "name"},
+	)
+
+	PrefixState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "herald_prefix_state",
+			Help: "Prefix announcement state (1 for the current state: withdrawn, announced, degraded or draining)",
+		},
+		[]string{"prefix", "name", "state"},
+	)
+
+	PrefixTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "herald_prefix_transitions_total",
+			Help: "Total number of prefix announcement state changes by new state",
+		},
+		[]string{"prefix", "name", "state"},
+	)
+
+	PrefixLastChange = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "herald_prefix_last_change_timestamp_seconds",
+			Help: "Unix timestamp of the last prefix announcement state change",
+		},
+		[]string{"prefix", "name"},
+	)
+
+	PrefixBandwidth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "herald_prefix_link_bandwidth_bits_per_second",
+			Help: "Bandwidth announced in the link bandwidth extended community of the prefix",
+		},
+		[]string{"prefix", "name"},
+	)
+
+	PrefixAdvertised = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "herald_prefix_advertised",
+			Help: "Prefix presence in the Adj-RIB-Out of a neighbor it is exported to (1=advertised, 0=missing)",
+		},
+		[]string{"prefix", "name", "peer_address"},
+	)
+
+	PrefixReinjections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "herald_prefix_reinjections_total",
+			Help: "Total number of announced prefixes found missing from an Adj-RIB-Out and announced again",
+		},
+		[]string{"prefix", "name"},
+	)
+
 	ProbeSuccess = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "herald_probe_success_total",
@@ -55,6 +111,29 @@ var (
 		[]string{"peer_address", "peer_asn"},
 	)
 
+	BGPPeerTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "herald_bgp_peer_transitions_total",
+			Help: "Total number of BGP peer session state changes by new state",
+		},
+		[]string{"peer_address", "peer_asn", "state"},
+	)
+
+	BGPPeerDown = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "herald_bgp_peer_down_total",
+			Help: "Total number of established BGP sessions that went down by reason",
+		},
+		[]string{"peer_address", "peer_asn", "reason"},
+	)
+
+	BGPEstablishedPeers = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "herald_bgp_established_peers",
+			Help: "Number of established BGP sessions",
+		},
+	)
+
 	BGPPeerMessagesSent = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "herald_bgp_peer_messages_sent_total",
@@ -71,12 +150,28 @@ var (
 		[]string{"peer_address", "peer_asn", "message_type"},
 	)
 
+	BGPPeerReceivedRoutes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "herald_bgp_peer_received_routes",
+			Help: "Number of routes received from peer by address family",
+		},
+		[]string{"peer_address", "peer_asn", "family"},
+	)
+
+	BGPPeerAcceptedRoutes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "herald_bgp_peer_accepted_routes",
+			Help: "Number of routes received from peer and accepted by import policies by address family",
+		},
+		[]string{"peer_address", "peer_asn", "family"},
+	)
+
 	BGPRouteCount = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "herald_bgp_route_count",
-			Help: "Number of BGP routes",
+			Help: "Number of BGP routes by address family",
 		},
-		[]string{"route_table"},
+		[]string{"route_table", "family"},
 	)
 
 	ServiceRestarts = promauto.NewCounterVec(
@@ -86,4 +181,26 @@ var (
 		},
 		[]string{"name"},
 	)
+
+	ConfigReloads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "herald_config_reloads_total",
+			Help: "Total number of configuration reloads by result (success, failure)",
+		},
+		[]string{"result"},
+	)
+
+	ConfigLastReloadSuccessful = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "herald_config_last_reload_successful",
+			Help: "Whether the last configuration reload succeeded (1=success, 0=failure)",
+		},
+	)
+
+	ConfigLastReloadSuccessTimestamp = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "herald_config_last_reload_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful configuration load",
+		},
+	)
 )
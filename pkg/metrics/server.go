@@ -14,6 +14,9 @@ type Server struct {
 	httpServer *http.Server
 	address    string
 	port       int
+	reload     func() error
+	events     http.Handler
+	routes     http.Handler
 }
 
 func NewServer(address string, port int) *Server {
@@ -23,6 +26,24 @@ func NewServer(address string, port int) *Server {
 	}
 }
 
+// SetReloadFunc enables the POST /-/reload endpoint, which calls reload and
+// reports its error to the client. It must be called before Start.
+func (s *Server) SetReloadFunc(reload func() error) {
+	s.reload = reload
+}
+
+// SetEventsHandler enables the GET /events endpoint, served by events. It
+// must be called before Start.
+func (s *Server) SetEventsHandler(events http.Handler) {
+	s.events = events
+}
+
+// SetRoutesHandler enables the GET /routes endpoint, served by routes. It
+// must be called before Start.
+func (s *Server) SetRoutesHandler(routes http.Handler) {
+	s.routes = routes
+}
+
 func (s *Server) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
@@ -32,6 +53,28 @@ func (s *Server) Start(ctx context.Context) error {
 		_, _ = w.Write([]byte("OK"))
 	})
 
+	if s.reload != nil {
+		mux.HandleFunc("/-/reload", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost && r.Method != http.MethodPut {
+				w.Header().Set("Allow", "POST, PUT")
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if err := s.reload(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte("OK"))
+		})
+	}
+
+	if s.events != nil {
+		mux.Handle("GET /events", s.events)
+	}
+	if s.routes != nil {
+		mux.Handle("GET /routes", s.routes)
+	}
+
 	s.httpServer = &http.Server{
 		Addr:              fmt.Sprintf("%s:%d", s.address, s.port),
 		Handler:           mux,
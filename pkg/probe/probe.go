@@ -17,9 +17,21 @@ type ProbeInterface interface {
 
 type ProbeStatus struct {
 	Status string `yaml:"status"`
+	// Output of exec probes and response body of HTTP probes, truncated to
+	// MaxOutputSize bytes.
+	Output string `yaml:"output"`
 }
 
+// MaxOutputSize is the number of bytes of output kept in a ProbeStatus.
+const MaxOutputSize = 4096
+
+// Probe is a health check run periodically, with exactly one of http, grpc,
+// exec or tcp set.
 type Probe struct {
+	// Name of the shared check this probe is based on. Prefixes referencing
+	// the same check without overriding any field share its executions.
+	Check string `yaml:"check"`
+
 	// Number of seconds after the service has started before liveness probes are initiated
 	//nolint:staticcheck // Field name matches Kubernetes API convention
 	InitialDelaySeconds time.Duration `yaml:"initialDelaySeconds"`
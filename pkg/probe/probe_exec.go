@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os/exec"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,12 +16,18 @@ import (
 // Ensure implements interface.
 var _ ProbeInterface = (*ProbeExec)(nil)
 
+// ProbeExec runs a command.
 type ProbeExec struct {
-	Command   string        `yaml:"command"`
-	Args      []string      `yaml:"args"`
-	User      string        `yaml:"user"`
-	Timeout   time.Duration `yaml:"timeout"`
-	ExitCodes []int         `yaml:"exitCodes"`
+	// Command to run.
+	Command string `yaml:"command"`
+	// Arguments of the command.
+	Args []string `yaml:"args"`
+	// User to run the command as.
+	User string `yaml:"user"`
+	// Command timeout.
+	Timeout time.Duration `yaml:"timeout"`
+	// Exit codes considered successful. Defaults to [0].
+	ExitCodes []int `yaml:"exitCodes"`
 }
 
 func (p *ProbeExec) Run(ctx context.Context) (*ProbeStatus, error) {
@@ -55,12 +62,17 @@ func (p *ProbeExec) Run(ctx context.Context) (*ProbeStatus, error) {
 	var wg sync.WaitGroup
 	wg.Add(2)
 
-	// Goroutine to stream stdout
+	// Goroutine to stream stdout, keeping the beginning as output
+	var output strings.Builder
 	go func() {
 		defer wg.Done()
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			zap.S().Debug("ProbeExec Run", scanner.Text())
+			if output.Len()+len(scanner.Text()) < MaxOutputSize {
+				output.WriteString(scanner.Text())
+				output.WriteByte('\n')
+			}
 		}
 	}()
 
@@ -83,10 +95,10 @@ func (p *ProbeExec) Run(ctx context.Context) (*ProbeStatus, error) {
 			if !slices.Contains(p.ExitCodes, exitCode) {
 				return nil, fmt.Errorf("ProbeExec Run: Unexpected exit code %d, expect in '%v'", exitCode, p.ExitCodes)
 			} else {
-				return &ProbeStatus{Status: "success"}, nil
+				return &ProbeStatus{Status: "success", Output: output.String()}, nil
 			}
 		}
 		return nil, fmt.Errorf("ProbeExec Run: Unwrap ExitCode %w", err)
 	}
-	return &ProbeStatus{Status: "success"}, nil
+	return &ProbeStatus{Status: "success", Output: output.String()}, nil
 }
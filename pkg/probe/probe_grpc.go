@@ -9,16 +9,36 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/ahmet2mir/herald/pkg/secret"
 )
 
 // Ensure implements interface.
 var _ ProbeInterface = (*ProbeGRPC)(nil)
 
+// GRPCMetadata is a metadata entry sent with gRPC health checks.
+type GRPCMetadata struct {
+	// Metadata key.
+	Name string `yaml:"name"`
+	// Metadata value.
+	Value secret.String `yaml:"value"`
+	// Source of the metadata value, instead of value.
+	ValueFrom *secret.Source `yaml:"valueFrom"`
+}
+
+// ProbeGRPC uses the gRPC health checking protocol.
 type ProbeGRPC struct {
-	Host    string        `yaml:"host"`
-	Port    int           `yaml:"port"`
-	Service string        `yaml:"service"`
+	// Target host. Defaults to localhost.
+	Host string `yaml:"host"`
+	// Target port.
+	Port int `yaml:"port"`
+	// Service name sent in the health check request.
+	Service string `yaml:"service"`
+	// Request timeout. Defaults to 1s.
 	Timeout time.Duration `yaml:"timeout"`
+	// Metadata sent with the request.
+	Metadata []GRPCMetadata `yaml:"metadata"`
 }
 
 func (p *ProbeGRPC) Run(ctx context.Context) (*ProbeStatus, error) {
@@ -54,6 +74,11 @@ func (p *ProbeGRPC) Run(ctx context.Context) (*ProbeStatus, error) {
 	// Create health check client
 	healthClient := grpc_health_v1.NewHealthClient(conn)
 
+	// Add custom metadata
+	for _, md := range p.Metadata {
+		ctx = metadata.AppendToOutgoingContext(ctx, md.Name, md.Value.Reveal())
+	}
+
 	// Perform health check
 	resp, err := healthClient.Check(ctx, &grpc_health_v1.HealthCheckRequest{
 		Service: p.Service,
@@ -9,23 +9,38 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/ahmet2mir/herald/pkg/secret"
 )
 
 // Ensure implements interface.
 var _ ProbeInterface = (*ProbeHTTP)(nil)
 
+// HTTPHeader is a header sent with HTTP probes.
 type HTTPHeader struct {
-	Name  string `yaml:"name"`
-	Value string `yaml:"value"`
+	// Header name.
+	Name string `yaml:"name"`
+	// Header value.
+	Value secret.String `yaml:"value"`
+	// Source of the header value, instead of value.
+	ValueFrom *secret.Source `yaml:"valueFrom"`
 }
 
+// ProbeHTTP performs an HTTP GET request.
 type ProbeHTTP struct {
-	Host           string        `yaml:"host"`
-	Port           int           `yaml:"port"`
-	Path           string        `yaml:"path"`
-	Scheme         string        `yaml:"scheme"`
-	HTTPHeaders    []HTTPHeader  `yaml:"httpHeaders"`
-	ExpectedStatus []int         `yaml:"expectedStatus"`
+	// Target host. Defaults to localhost.
+	Host string `yaml:"host"`
+	// Target port.
+	Port int `yaml:"port"`
+	// Request path. Defaults to /.
+	Path string `yaml:"path"`
+	// http or https. Defaults to http.
+	Scheme string `yaml:"scheme"`
+	// Headers sent with the request.
+	HTTPHeaders []HTTPHeader `yaml:"httpHeaders"`
+	// Status codes considered successful. Defaults to [200].
+	ExpectedStatus []int `yaml:"expectedStatus"`
+	// Request timeout. Defaults to 1s.
 	RequestTimeout time.Duration `yaml:"requestTimeout"`
 }
 
@@ -63,7 +78,7 @@ func (p *ProbeHTTP) Run(ctx context.Context) (*ProbeStatus, error) {
 
 	// Add custom headers
 	for _, header := range p.HTTPHeaders {
-		req.Header.Add(header.Name, header.Value)
+		req.Header.Add(header.Name, header.Value.Reveal())
 	}
 
 	// Execute request
@@ -77,7 +92,11 @@ func (p *ProbeHTTP) Run(ctx context.Context) (*ProbeStatus, error) {
 		}
 	}()
 
-	// Drain and close body to allow connection reuse
+	// Keep the beginning of the body, then drain it to allow connection reuse
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, MaxOutputSize))
+	if readErr != nil {
+		zap.S().Debug("ProbeHTTP Run: error reading response body", readErr)
+	}
 	if _, copyErr := io.Copy(io.Discard, resp.Body); copyErr != nil {
 		zap.S().Debug("ProbeHTTP Run: error draining response body", copyErr)
 	}
@@ -88,5 +107,5 @@ func (p *ProbeHTTP) Run(ctx context.Context) (*ProbeStatus, error) {
 	}
 
 	zap.S().Debug("ProbeHTTP Run", "status", resp.StatusCode, "success", true)
-	return &ProbeStatus{Status: "success"}, nil
+	return &ProbeStatus{Status: "success", Output: string(body)}, nil
 }
@@ -12,9 +12,13 @@ import (
 // Ensure implements interface.
 var _ ProbeInterface = (*ProbeTCP)(nil)
 
+// ProbeTCP opens a TCP connection.
 type ProbeTCP struct {
-	Host    string        `yaml:"host"`
-	Port    int           `yaml:"port"`
+	// Target host. Defaults to localhost.
+	Host string `yaml:"host"`
+	// Target port.
+	Port int `yaml:"port"`
+	// Connection timeout. Defaults to 1s.
 	Timeout time.Duration `yaml:"timeout"`
 }
 
@@ -0,0 +1,185 @@
+package probe
+
+import (
+	"time"
+
+	"github.com/ahmet2mir/herald/pkg/secret"
+	"github.com/ahmet2mir/herald/pkg/validation"
+)
+
+// Defaults applied to unset probe fields, following the Kubernetes probe API.
+const (
+	DefaultPeriodSeconds    = 10 * time.Second
+	DefaultTimeoutSeconds   = 1 * time.Second
+	DefaultFailureThreshold = 3
+	DefaultSuccessThreshold = 1
+)
+
+// SetDefaults fills unset timing and threshold fields with their defaults.
+func (p *Probe) SetDefaults() {
+	if p.PeriodSeconds == 0 {
+		p.PeriodSeconds = DefaultPeriodSeconds
+	}
+	if p.TimeoutSeconds == 0 {
+		p.TimeoutSeconds = DefaultTimeoutSeconds
+	}
+	if p.FailureThreshold == 0 {
+		p.FailureThreshold = DefaultFailureThreshold
+	}
+	if p.SuccessThreshold == 0 {
+		p.SuccessThreshold = DefaultSuccessThreshold
+	}
+}
+
+// Validate applies defaults and records every invalid field of the probe
+// found at path.
+func (p *Probe) Validate(path string, errs *validation.Errors) {
+	p.SetDefaults()
+
+	if p.InitialDelaySeconds < 0 {
+		errs.Addf(validation.Field(path, "initialDelaySeconds"), "must not be negative, got %s", p.InitialDelaySeconds)
+	}
+	if p.TerminationGracePeriodSeconds < 0 {
+		errs.Addf(validation.Field(path, "terminationGracePeriodSeconds"), "must not be negative, got %s", p.TerminationGracePeriodSeconds)
+	}
+	if p.PeriodSeconds < time.Second {
+		errs.Addf(validation.Field(path, "periodSeconds"), "must be at least 1s, got %s", p.PeriodSeconds)
+	}
+	if p.TimeoutSeconds < 0 {
+		errs.Addf(validation.Field(path, "timeoutSeconds"), "must not be negative, got %s", p.TimeoutSeconds)
+	}
+	if p.FailureThreshold < 1 {
+		errs.Addf(validation.Field(path, "failureThreshold"), "must be at least 1, got %d", p.FailureThreshold)
+	}
+	if p.SuccessThreshold < 1 {
+		errs.Addf(validation.Field(path, "successThreshold"), "must be at least 1, got %d", p.SuccessThreshold)
+	}
+
+	configured := 0
+	if p.ProbeHTTP != nil {
+		configured++
+		p.ProbeHTTP.validate(validation.Field(path, "http"), errs)
+	}
+	if p.ProbeGRPC != nil {
+		configured++
+		p.ProbeGRPC.validate(validation.Field(path, "grpc"), errs)
+	}
+	if p.ProbeExec != nil {
+		configured++
+		p.ProbeExec.validate(validation.Field(path, "exec"), errs)
+	}
+	if p.ProbeTCP != nil {
+		configured++
+		p.ProbeTCP.validate(validation.Field(path, "tcp"), errs)
+	}
+	switch {
+	case configured == 0:
+		errs.Addf(path, "one of http, grpc, exec or tcp must be set")
+	case configured > 1:
+		errs.Addf(path, "only one of http, grpc, exec or tcp may be set")
+	}
+}
+
+func (p *ProbeHTTP) validate(path string, errs *validation.Errors) {
+	if p.Scheme == "" {
+		p.Scheme = "http"
+	}
+	if p.Path == "" {
+		p.Path = "/"
+	}
+	if p.Host == "" {
+		p.Host = "localhost"
+	}
+	if p.ExpectedStatus == nil {
+		p.ExpectedStatus = []int{200}
+	}
+	if p.RequestTimeout == 0 {
+		p.RequestTimeout = 1 * time.Second
+	}
+
+	validatePort(validation.Field(path, "port"), p.Port, errs)
+	if p.Scheme != "" && p.Scheme != "http" && p.Scheme != "https" {
+		errs.Addf(validation.Field(path, "scheme"), "must be http or https, got %q", p.Scheme)
+	}
+	for i, status := range p.ExpectedStatus {
+		if status < 100 || status > 599 {
+			errs.Addf(validation.Index(validation.Field(path, "expectedStatus"), i), "invalid HTTP status code %d", status)
+		}
+	}
+	for i := range p.HTTPHeaders {
+		header := &p.HTTPHeaders[i]
+		headerPath := validation.Index(validation.Field(path, "httpHeaders"), i)
+		if header.Name == "" {
+			errs.Addf(validation.Field(headerPath, "name"), "is required")
+		}
+		resolveValue(headerPath, &header.Value, header.ValueFrom, errs)
+	}
+	if p.RequestTimeout < 0 {
+		errs.Addf(validation.Field(path, "requestTimeout"), "must not be negative, got %s", p.RequestTimeout)
+	}
+}
+
+func (p *ProbeGRPC) validate(path string, errs *validation.Errors) {
+	if p.Host == "" {
+		p.Host = "localhost"
+	}
+	if p.Timeout == 0 {
+		p.Timeout = 1 * time.Second
+	}
+
+	validatePort(validation.Field(path, "port"), p.Port, errs)
+	if p.Timeout < 0 {
+		errs.Addf(validation.Field(path, "timeout"), "must not be negative, got %s", p.Timeout)
+	}
+	for i := range p.Metadata {
+		md := &p.Metadata[i]
+		mdPath := validation.Index(validation.Field(path, "metadata"), i)
+		if md.Name == "" {
+			errs.Addf(validation.Field(mdPath, "name"), "is required")
+		}
+		resolveValue(mdPath, &md.Value, md.ValueFrom, errs)
+	}
+}
+
+func (p *ProbeExec) validate(path string, errs *validation.Errors) {
+	if p.ExitCodes == nil {
+		p.ExitCodes = []int{0}
+	}
+	if p.Args == nil {
+		p.Args = []string{}
+	}
+
+	if p.Command == "" {
+		errs.Addf(validation.Field(path, "command"), "is required")
+	}
+	if p.Timeout < 0 {
+		errs.Addf(validation.Field(path, "timeout"), "must not be negative, got %s", p.Timeout)
+	}
+}
+
+func (p *ProbeTCP) validate(path string, errs *validation.Errors) {
+	if p.Host == "" {
+		p.Host = "localhost"
+	}
+	if p.Timeout == 0 {
+		p.Timeout = 1 * time.Second
+	}
+
+	validatePort(validation.Field(path, "port"), p.Port, errs)
+	if p.Timeout < 0 {
+		errs.Addf(validation.Field(path, "timeout"), "must not be negative, got %s", p.Timeout)
+	}
+}
+
+func validatePort(path string, port int, errs *validation.Errors) {
+	if port < 1 || port > 65535 {
+		errs.Addf(path, "must be between 1 and 65535, got %d", port)
+	}
+}
+
+// resolveValue reads value from its valueFrom source, if any.
+func resolveValue(path string, value *secret.String, from *secret.Source, errs *validation.Errors) {
+	if err := secret.Resolve(value, from); err != nil {
+		errs.Add(validation.Field(path, "valueFrom"), err)
+	}
+}
@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"github.com/ahmet2mir/herald/pkg/probe"
+)
+
+// result is the outcome of one probe execution.
+type result struct {
+	status   *probe.ProbeStatus
+	err      error
+	duration time.Duration
+	pm       *probe.ProbeManager
+}
+
+// checkRegistry runs named checks shared by several prefixes once per period
+// and fans each result out to every subscribed prefix.
+type checkRegistry struct {
+	ctx context.Context
+
+	mu     sync.Mutex
+	checks map[string][]*sharedCheck
+}
+
+type sharedCheck struct {
+	name        string
+	probe       *probe.Probe
+	pm          *probe.ProbeManager
+	cron        *cron.Cron
+	subscribers map[*subscription]struct{}
+}
+
+type subscription struct {
+	fn func(result)
+	wg sync.WaitGroup
+}
+
+func newCheckRegistry(ctx context.Context) *checkRegistry {
+	return &checkRegistry{ctx: ctx, checks: map[string][]*sharedCheck{}}
+}
+
+// subscribe calls fn with every result of the check p, starting it if it is
+// not running yet. Probes are shared when they reference the same check and
+// are identical, i.e. no field of the check was overridden. The returned
+// function unsubscribes and waits for fn calls in progress.
+func (r *checkRegistry) subscribe(p *probe.Probe, fn func(result)) (func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var check *sharedCheck
+	for _, c := range r.checks[p.Check] {
+		if reflect.DeepEqual(c.probe, p) {
+			check = c
+			break
+		}
+	}
+	if check == nil {
+		check = &sharedCheck{
+			name:        p.Check,
+			probe:       p,
+			pm:          probe.NewProbeManager(),
+			cron:        cron.New(cron.WithSeconds()),
+			subscribers: map[*subscription]struct{}{},
+		}
+		if _, err := check.cron.AddFunc("@every "+p.PeriodSeconds.String(), func() { r.run(check) }); err != nil {
+			return nil, err
+		}
+		check.cron.Start()
+		r.checks[p.Check] = append(r.checks[p.Check], check)
+		zap.S().Info("Starting shared check", "check", p.Check)
+	}
+
+	sub := &subscription{fn: fn}
+	check.subscribers[sub] = struct{}{}
+	return func() { r.unsubscribe(check, sub) }, nil
+}
+
+func (r *checkRegistry) unsubscribe(check *sharedCheck, sub *subscription) {
+	r.mu.Lock()
+	delete(check.subscribers, sub)
+	stop := len(check.subscribers) == 0
+	if stop {
+		checks := r.checks[check.name]
+		for i, c := range checks {
+			if c == check {
+				r.checks[check.name] = append(checks[:i], checks[i+1:]...)
+				break
+			}
+		}
+		if len(r.checks[check.name]) == 0 {
+			delete(r.checks, check.name)
+		}
+	}
+	r.mu.Unlock()
+
+	if stop {
+		zap.S().Info("Stopping shared check", "check", check.name)
+		<-check.cron.Stop().Done()
+	}
+	sub.wg.Wait()
+}
+
+// run executes the check once and hands the result to every subscriber.
+func (r *checkRegistry) run(check *sharedCheck) {
+	start := time.Now()
+	status, err := check.pm.Run(r.ctx, check.probe, nil)
+	res := result{status: status, err: err, duration: time.Since(start), pm: check.pm}
+
+	r.mu.Lock()
+	subs := make([]*subscription, 0, len(check.subscribers))
+	for sub := range check.subscribers {
+		sub.wg.Add(1)
+		subs = append(subs, sub)
+	}
+	r.mu.Unlock()
+
+	for _, sub := range subs {
+		go func(sub *subscription) {
+			defer sub.wg.Done()
+			sub.fn(res)
+		}(sub)
+	}
+}
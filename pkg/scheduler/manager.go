@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+	"github.com/ahmet2mir/herald/pkg/speaker"
+)
+
+// Manager runs one scheduler per prefix and applies configuration changes
+// by starting, stopping or restarting only the schedulers that changed.
+type Manager struct {
+	ctx     context.Context
+	speaker *speaker.Speaker
+	checks  *checkRegistry
+
+	mu      sync.Mutex
+	running map[string]*runningScheduler
+}
+
+type runningScheduler struct {
+	prefix    config.Prefix
+	announcer *announcer
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// SyncResult summarizes what Manager.Sync changed.
+type SyncResult struct {
+	Added     []string
+	Removed   []string
+	Changed   []string
+	Unchanged int
+}
+
+func NewManager(ctx context.Context, s *speaker.Speaker) *Manager {
+	return &Manager{
+		ctx:     ctx,
+		speaker: s,
+		checks:  newCheckRegistry(ctx),
+		running: map[string]*runningScheduler{},
+	}
+}
+
+// Sync makes the running schedulers match prefixes, keyed by IP address.
+// Removed prefixes are stopped and withdrawn, changed prefixes are restarted
+// with their new settings and the probe state of their previous scheduler so
+// the next readiness result re-announces them with the new attributes, and
+// unchanged prefixes are left alone.
+func (m *Manager) Sync(prefixes []config.Prefix) SyncResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result SyncResult
+	wanted := make(map[string]config.Prefix, len(prefixes))
+	for _, p := range prefixes {
+		wanted[p.IPAddress] = p
+	}
+
+	for key, rs := range m.running {
+		if _, ok := wanted[key]; ok {
+			continue
+		}
+		rs.stop()
+		delete(m.running, key)
+		if err := m.speaker.DeletePath(rs.prefix); err != nil {
+			zap.S().Error("Failed to withdraw removed prefix", "prefix", key, "error", err)
+		}
+		result.Removed = append(result.Removed, key)
+	}
+
+	for _, p := range prefixes {
+		a := &announcer{prefix: p, speaker: m.speaker}
+		rs, ok := m.running[p.IPAddress]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, p.IPAddress)
+		case reflect.DeepEqual(rs.prefix, p):
+			result.Unchanged++
+			continue
+		default:
+			rs.stop()
+			a.inherit(rs.announcer)
+			result.Changed = append(result.Changed, p.IPAddress)
+		}
+		m.running[p.IPAddress] = m.start(a)
+	}
+
+	return result
+}
+
+// Stop stops every scheduler and waits for them to return.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, rs := range m.running {
+		rs.stop()
+		delete(m.running, key)
+	}
+}
+
+func (m *Manager) start(a *announcer) *runningScheduler {
+	ctx, cancel := context.WithCancel(m.ctx)
+	rs := &runningScheduler{prefix: a.prefix, announcer: a, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(rs.done)
+		runScheduler(ctx, a, m.checks)
+	}()
+	return rs
+}
+
+func (rs *runningScheduler) stop() {
+	rs.cancel()
+	<-rs.done
+}
@@ -2,6 +2,9 @@ package scheduler
 
 import (
 	"context"
+	"math"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -13,8 +16,44 @@ import (
 	"github.com/ahmet2mir/herald/pkg/speaker"
 )
 
+// RunScheduler runs the probes of prefix p and announces or withdraws it
+// through s. It blocks until ctx is cancelled, then waits for running probes
+// to finish.
 func RunScheduler(ctx context.Context, p config.Prefix, s *speaker.Speaker) {
+	runScheduler(ctx, &announcer{prefix: p, speaker: s}, nil)
+}
+
+// runScheduler is RunScheduler announcing through a, with probes referencing
+// a named check served by checks when it is not nil.
+func runScheduler(ctx context.Context, a *announcer, checks *checkRegistry) {
+	p, s := a.prefix, a.speaker
 	cron := cron.New(cron.WithSeconds())
+	var unsubscribes []func()
+	defer func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}()
+
+	// schedule calls fn with the result of pr every period, from a shared
+	// check when possible.
+	schedule := func(pr *probe.Probe, fn func(result)) error {
+		if checks != nil && pr.Check != "" {
+			unsubscribe, err := checks.subscribe(pr, fn)
+			if err != nil {
+				return err
+			}
+			unsubscribes = append(unsubscribes, unsubscribe)
+			return nil
+		}
+		pm := probe.NewProbeManager()
+		_, err := cron.AddFunc("@every "+pr.PeriodSeconds.String(), func() {
+			start := time.Now()
+			status, err := pm.Run(ctx, pr, p.Service)
+			fn(result{status: status, err: err, duration: time.Since(start), pm: pm})
+		})
+		return err
+	}
 
 	svc, err := p.Service.Started(ctx)
 	if err != nil || !svc {
@@ -25,7 +64,9 @@ func RunScheduler(ctx context.Context, p config.Prefix, s *speaker.Speaker) {
 	if p.StartupProbe != nil {
 		if p.StartupProbe.InitialDelaySeconds > 0 {
 			zap.S().Info("p.StartupProbe.InitialDelaySeconds", p.StartupProbe.InitialDelaySeconds)
-			time.Sleep(p.StartupProbe.InitialDelaySeconds)
+			if !sleep(ctx, p.StartupProbe.InitialDelaySeconds) {
+				return
+			}
 		}
 
 		pm := probe.NewProbeManager()
@@ -38,7 +79,7 @@ func RunScheduler(ctx context.Context, p config.Prefix, s *speaker.Speaker) {
 			metrics.ProbeFailure.WithLabelValues(p.IPAddress, "startup", p.Name).Inc()
 			zap.S().Warn(err)
 			zap.S().Info("p.StartupProbe.PeriodSeconds", p.StartupProbe.PeriodSeconds)
-			time.Sleep(p.StartupProbe.PeriodSeconds)
+			sleep(ctx, p.StartupProbe.PeriodSeconds)
 			return
 		}
 		metrics.ProbeSuccess.WithLabelValues(p.IPAddress, "startup", p.Name).Inc()
@@ -47,11 +88,12 @@ func RunScheduler(ctx context.Context, p config.Prefix, s *speaker.Speaker) {
 	if p.LivenessProbe != nil {
 		if p.LivenessProbe.InitialDelaySeconds > 0 {
 			zap.S().Info("p.LivenessProbe.InitialDelaySeconds", p.LivenessProbe.InitialDelaySeconds)
-			time.Sleep(p.LivenessProbe.InitialDelaySeconds)
+			if !sleep(ctx, p.LivenessProbe.InitialDelaySeconds) {
+				return
+			}
 		}
 
-		pm := probe.NewProbeManager()
-		_, err := cron.AddFunc("@every "+p.LivenessProbe.PeriodSeconds.String(), func() {
+		err := schedule(p.LivenessProbe, func(r result) {
 			svc, err := p.Service.Started(ctx)
 			if err != nil || !svc {
 				zap.S().Warn(err)
@@ -60,24 +102,21 @@ func RunScheduler(ctx context.Context, p config.Prefix, s *speaker.Speaker) {
 				} else {
 					metrics.ServiceRestarts.WithLabelValues(p.Name).Inc()
 				}
-			} else {
-				start := time.Now()
-				status, err := pm.Run(ctx, p.LivenessProbe, p.Service)
-				duration := time.Since(start).Seconds()
-
-				metrics.ProbeDuration.WithLabelValues(p.IPAddress, "liveness", p.Name).Observe(duration)
-				if err != nil {
-					metrics.ProbeFailure.WithLabelValues(p.IPAddress, "liveness", p.Name).Inc()
-					zap.S().Error("SchedulerProbeError: LivenessProbe", err, "  ", pm.NumberFailure, "==", pm.NumberSuccess)
-					if _, restartErr := p.Service.Restart(ctx); restartErr != nil {
-						zap.S().Error("Failed to restart service", restartErr)
-					} else {
-						metrics.ServiceRestarts.WithLabelValues(p.Name).Inc()
-					}
+				return
+			}
+
+			metrics.ProbeDuration.WithLabelValues(p.IPAddress, "liveness", p.Name).Observe(r.duration.Seconds())
+			if r.err != nil {
+				metrics.ProbeFailure.WithLabelValues(p.IPAddress, "liveness", p.Name).Inc()
+				zap.S().Error("SchedulerProbeError: LivenessProbe", r.err, "  ", r.pm.NumberFailure, "==", r.pm.NumberSuccess)
+				if _, restartErr := p.Service.Restart(ctx); restartErr != nil {
+					zap.S().Error("Failed to restart service", restartErr)
 				} else {
-					metrics.ProbeSuccess.WithLabelValues(p.IPAddress, "liveness", p.Name).Inc()
-					zap.S().Info("SchedulerProbe: LivenessProbe", status.Status, "  ", pm.NumberFailure, "==", pm.NumberSuccess)
+					metrics.ServiceRestarts.WithLabelValues(p.Name).Inc()
 				}
+			} else {
+				metrics.ProbeSuccess.WithLabelValues(p.IPAddress, "liveness", p.Name).Inc()
+				zap.S().Info("SchedulerProbe: LivenessProbe", r.status.Status, "  ", r.pm.NumberFailure, "==", r.pm.NumberSuccess)
 			}
 		})
 		if err != nil {
@@ -88,31 +127,21 @@ func RunScheduler(ctx context.Context, p config.Prefix, s *speaker.Speaker) {
 	if p.ReadinessProbe != nil {
 		if p.ReadinessProbe.InitialDelaySeconds > 0 {
 			zap.S().Info("p.ReadinessProbe.InitialDelaySeconds", p.ReadinessProbe.InitialDelaySeconds)
-			time.Sleep(p.ReadinessProbe.InitialDelaySeconds)
+			if !sleep(ctx, p.ReadinessProbe.InitialDelaySeconds) {
+				return
+			}
 		}
 
-		_, err := cron.AddFunc("@every "+p.ReadinessProbe.PeriodSeconds.String(), func() {
-			pm := probe.NewProbeManager()
-			start := time.Now()
-			status, err := pm.Run(ctx, p.ReadinessProbe, p.Service)
-			duration := time.Since(start).Seconds()
-
-			metrics.ProbeDuration.WithLabelValues(p.IPAddress, "readiness", p.Name).Observe(duration)
-			if err != nil {
+		err := schedule(p.ReadinessProbe, func(r result) {
+			metrics.ProbeDuration.WithLabelValues(p.IPAddress, "readiness", p.Name).Observe(r.duration.Seconds())
+			if r.err != nil {
 				metrics.ProbeFailure.WithLabelValues(p.IPAddress, "readiness", p.Name).Inc()
-				metrics.PrefixUp.WithLabelValues(p.IPAddress, p.Name).Set(0)
-				zap.S().Error("SchedulerProbeError: ReadinessProbe => %w", err, "  ", pm.NumberFailure, "==", pm.NumberSuccess)
-				if delErr := s.DeletePath(p); delErr != nil {
-					zap.S().Error("Failed to delete path", delErr)
-				}
+				zap.S().Error("SchedulerProbeError: ReadinessProbe => %w", r.err, "  ", r.pm.NumberFailure, "==", r.pm.NumberSuccess)
 			} else {
 				metrics.ProbeSuccess.WithLabelValues(p.IPAddress, "readiness", p.Name).Inc()
-				metrics.PrefixUp.WithLabelValues(p.IPAddress, p.Name).Set(1)
-				zap.S().Info("SchedulerProbe: ReadinessProbe => %s", status.Status, "  ", pm.NumberFailure, "==", pm.NumberSuccess, "AddPath")
-				if err := s.AddPath(p); err != nil {
-					zap.S().Error("SchedulerProbeError: Failed to addpath", err)
-				}
+				zap.S().Info("SchedulerProbe: ReadinessProbe => %s", r.status.Status, "  ", r.pm.NumberFailure, "==", r.pm.NumberSuccess, "AddPath")
 			}
+			a.readiness(r.err == nil)
 		})
 		if err != nil {
 			zap.S().Error("SchedulerProbeError: Failed to schedule ReadinessProbe => %w", err)
@@ -122,5 +151,312 @@ func RunScheduler(ctx context.Context, p config.Prefix, s *speaker.Speaker) {
 		}
 	}
 
+	if p.DegradedProbe != nil {
+		if p.DegradedProbe.InitialDelaySeconds > 0 {
+			zap.S().Info("p.DegradedProbe.InitialDelaySeconds", p.DegradedProbe.InitialDelaySeconds)
+			if !sleep(ctx, p.DegradedProbe.InitialDelaySeconds) {
+				return
+			}
+		}
+
+		err := schedule(p.DegradedProbe, func(r result) {
+			metrics.ProbeDuration.WithLabelValues(p.IPAddress, "degraded", p.Name).Observe(r.duration.Seconds())
+			if r.err != nil {
+				metrics.ProbeFailure.WithLabelValues(p.IPAddress, "degraded", p.Name).Inc()
+				zap.S().Warn("SchedulerProbeError: DegradedProbe => %w", r.err, "  ", r.pm.NumberFailure, "==", r.pm.NumberSuccess)
+			} else {
+				metrics.ProbeSuccess.WithLabelValues(p.IPAddress, "degraded", p.Name).Inc()
+			}
+			a.degradedProbe(r.err == nil)
+		})
+		if err != nil {
+			zap.S().Error("SchedulerProbeError: Failed to schedule DegradedProbe", err)
+		}
+	}
+
+	if lb := p.LinkBandwidth; lb != nil && lb.Bandwidth != "" {
+		if bps, err := config.ParseBandwidth(lb.Bandwidth); err == nil {
+			metrics.PrefixBandwidth.WithLabelValues(p.IPAddress, p.Name).Set(bps)
+		}
+	}
+	if p.BandwidthProbe != nil {
+		if p.BandwidthProbe.InitialDelaySeconds > 0 {
+			zap.S().Info("p.BandwidthProbe.InitialDelaySeconds", p.BandwidthProbe.InitialDelaySeconds)
+			if !sleep(ctx, p.BandwidthProbe.InitialDelaySeconds) {
+				return
+			}
+		}
+
+		err := schedule(p.BandwidthProbe, func(r result) {
+			metrics.ProbeDuration.WithLabelValues(p.IPAddress, "bandwidth", p.Name).Observe(r.duration.Seconds())
+			if r.err != nil {
+				metrics.ProbeFailure.WithLabelValues(p.IPAddress, "bandwidth", p.Name).Inc()
+				zap.S().Warn("SchedulerProbeError: BandwidthProbe => %w", r.err)
+				return
+			}
+			metrics.ProbeSuccess.WithLabelValues(p.IPAddress, "bandwidth", p.Name).Inc()
+			a.bandwidthProbe(r.status.Output)
+		})
+		if err != nil {
+			zap.S().Error("SchedulerProbeError: Failed to schedule BandwidthProbe", err)
+		}
+	}
+
 	cron.Start()
+	<-ctx.Done()
+	<-cron.Stop().Done()
+}
+
+// announcer announces, degrades or withdraws a prefix from the results of
+// its readiness and degraded probes, which may arrive concurrently.
+type announcer struct {
+	prefix  config.Prefix
+	speaker *speaker.Speaker
+
+	mu sync.Mutex
+	// ready is set once the readiness probe has returned a result.
+	ready bool
+	// readinessGate and degradedGate apply the thresholds of the readiness
+	// and degraded probes.
+	readinessGate gate
+	degradedGate  gate
+	// healthy is set once the readiness probe passed, so that a prefix that
+	// never was healthy is not announced degraded.
+	healthy bool
+	// drainStart is when the prefix started draining for maintenance.
+	drainStart time.Time
+	// bandwidth is the last bandwidth announced from the bandwidth probe,
+	// in bits per second, when bandwidthProbed is set.
+	bandwidth       float64
+	bandwidthProbed bool
+	// waitingPeers is set while fewer sessions than minEstablishedPeers are
+	// established.
+	waitingPeers bool
+	// routesMissing is set while none of the routes of the route condition
+	// is received.
+	routesMissing bool
+}
+
+func (a *announcer) readiness(ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ready = true
+	a.readinessGate.record(ok, a.prefix.ReadinessProbe)
+	if a.readinessGate.passing {
+		a.healthy = true
+	}
+	a.apply()
+}
+
+func (a *announcer) degradedProbe(ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.degradedGate.record(ok, a.prefix.DegradedProbe)
+	if a.ready {
+		a.apply()
+	}
+}
+
+// gate counts the consecutive results of a probe. The probe is passing after
+// successThreshold successes in a row and failing after failureThreshold
+// failures in a row, and neither until one of them is reached.
+type gate struct {
+	failures  int32
+	successes int32
+	passing   bool
+	failing   bool
+}
+
+func (g *gate) record(ok bool, pr *probe.Probe) {
+	if ok {
+		g.failures = 0
+		g.successes++
+		if g.successes >= pr.SuccessThreshold {
+			g.passing, g.failing = true, false
+		}
+		return
+	}
+	g.successes = 0
+	g.failures++
+	if g.failures >= pr.FailureThreshold {
+		g.passing, g.failing = false, true
+	}
+}
+
+// bandwidthProbe records the bandwidth output by the bandwidth probe and
+// announces it when it changed by more than the hysteresis.
+func (a *announcer) bandwidthProbe(output string) {
+	p := a.prefix
+	bps, err := config.ParseBandwidth(output)
+	if err != nil {
+		zap.S().Warn("Invalid bandwidth probe output", "prefix", p.IPAddress, "error", err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.bandwidthProbed {
+		change := math.Abs(bps - a.bandwidth)
+		if change == 0 || (a.bandwidth != 0 && change*100 <= a.bandwidth*float64(p.LinkBandwidth.Hysteresis)) {
+			return
+		}
+	}
+	zap.S().Info("Bandwidth changed", "prefix", p.IPAddress, "from", a.bandwidth, "to", bps)
+	a.bandwidth = bps
+	a.bandwidthProbed = true
+	metrics.PrefixBandwidth.WithLabelValues(p.IPAddress, p.Name).Set(bps)
+	if a.ready {
+		a.apply()
+	}
+}
+
+// inherit takes the probe state of prev, the announcer of the same prefix
+// before it was restarted with new settings, so that the prefix stays as it
+// is until its probes reach a threshold again.
+func (a *announcer) inherit(prev *announcer) {
+	prev.mu.Lock()
+	defer prev.mu.Unlock()
+	a.ready = prev.ready
+	a.readinessGate = prev.readinessGate
+	a.degradedGate = prev.degradedGate
+	a.healthy = prev.healthy
+	a.drainStart = prev.drainStart
+}
+
+// current returns the prefix with the probed bandwidth.
+func (a *announcer) current() config.Prefix {
+	if a.bandwidthProbed {
+		return a.prefix.WithBandwidth(a.bandwidth)
+	}
+	return a.prefix
+}
+
+// apply withdraws the prefix while its route condition is not met, until the
+// readiness probe first passes, and while it fails with withdrawOnDown or
+// failed withdraw threshold times in a row. Otherwise the prefix is
+// announced, with the degraded attributes while the readiness or degraded
+// probe fails. Prefixes in maintenance are drained, then withdrawn.
+func (a *announcer) apply() {
+	p := a.current()
+	d := p.Degraded
+	if !a.peersReady() {
+		return
+	}
+	maintenance := a.maintenance()
+	down := !a.readinessGate.passing
+	degraded := down || a.degradedGate.failing
+	switch {
+	case !a.routesReceived():
+		if err := a.speaker.DeletePath(p); err != nil {
+			zap.S().Error("Failed to delete path", err)
+		}
+	case !a.healthy || (down && *p.WithdrawOnDown),
+		down && d != nil && d.WithdrawThreshold > 0 && a.readinessGate.failures >= d.WithdrawThreshold:
+		if err := a.speaker.DeletePath(p); err != nil {
+			zap.S().Error("Failed to delete path", err)
+		}
+	case maintenance:
+		a.drain(p, degraded)
+	case degraded:
+		if err := a.speaker.DegradePath(p); err != nil {
+			zap.S().Error("SchedulerProbeError: Failed to addpath", err)
+		}
+	default:
+		if err := a.speaker.AddPath(p); err != nil {
+			zap.S().Error("SchedulerProbeError: Failed to addpath", err)
+		}
+	}
+}
+
+// peersReady reports whether enough sessions with the neighbors of the
+// prefix are established for probe results to be acted upon.
+func (a *announcer) peersReady() bool {
+	p := a.prefix
+	if p.MinEstablishedPeers == 0 {
+		return true
+	}
+	established := a.speaker.EstablishedPeers(p.Neighbors)
+	waiting := established < p.MinEstablishedPeers
+	if waiting != a.waitingPeers {
+		if waiting {
+			zap.S().Warn("Waiting for established peers", "prefix", p.IPAddress, "established", established, "minEstablishedPeers", p.MinEstablishedPeers)
+		} else {
+			zap.S().Info("Enough established peers", "prefix", p.IPAddress, "established", established)
+		}
+		a.waitingPeers = waiting
+	}
+	return !waiting
+}
+
+// routesReceived reports whether a route of the route condition of the
+// prefix is received, or true without a route condition.
+func (a *announcer) routesReceived() bool {
+	p := a.prefix
+	rc := p.RouteCondition
+	if rc == nil {
+		return true
+	}
+	received, err := a.speaker.Receiving(rc.Prefixes, rc.Neighbors)
+	if err != nil {
+		zap.S().Error("Failed to check route condition", "prefix", p.IPAddress, "error", err)
+	}
+	if received == a.routesMissing {
+		if received {
+			zap.S().Info("Route condition met", "prefix", p.IPAddress)
+		} else {
+			zap.S().Warn("Route condition not met, withdrawing", "prefix", p.IPAddress, "routes", rc.Prefixes)
+		}
+		a.routesMissing = !received
+	}
+	return received
+}
+
+// maintenance reports whether the maintenance file of the prefix exists,
+// recording when the maintenance started.
+func (a *announcer) maintenance() bool {
+	p := a.prefix
+	if p.Maintenance == "" {
+		return false
+	}
+	if _, err := os.Stat(p.Maintenance); err != nil {
+		if !a.drainStart.IsZero() {
+			zap.S().Info("Maintenance ended", "prefix", p.IPAddress)
+			a.drainStart = time.Time{}
+		}
+		return false
+	}
+	if a.drainStart.IsZero() {
+		a.drainStart = time.Now()
+		zap.S().Warn("Prefix in maintenance", "prefix", p.IPAddress, "file", p.Maintenance)
+	}
+	return true
+}
+
+// drain drains p for the drain period of the graceful shutdown settings
+// since the maintenance started, then withdraws it. A prefix that was not
+// announced when the maintenance started stays withdrawn.
+func (a *announcer) drain(p config.Prefix, degraded bool) {
+	gs := a.speaker.Config().Speaker.GracefulShutdown
+	draining := gs != nil && gs.Enabled && time.Since(a.drainStart) < gs.DrainPeriod
+	if draining && a.speaker.State(p.IPAddress).State != speaker.StateWithdrawn {
+		if err := a.speaker.DrainPath(p, degraded); err != nil {
+			zap.S().Error("Failed to drain path", err)
+		}
+		return
+	}
+	if err := a.speaker.DeletePath(p); err != nil {
+		zap.S().Error("Failed to delete path", err)
+	}
+}
+
+// sleep waits for d or until ctx is cancelled and reports whether the full
+// duration elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
@@ -0,0 +1,274 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+	"github.com/ahmet2mir/herald/pkg/probe"
+	"github.com/ahmet2mir/herald/pkg/speaker"
+)
+
+// newTestSpeaker starts a speaker without neighbors, announcing to nobody.
+func newTestSpeaker(t *testing.T, gs *config.GracefulShutdown) *speaker.Speaker {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &config.Config{
+		API:     config.ConfigAPI{ListenAddress: "127.0.0.1", ListenPort: 0},
+		Speaker: config.Speaker{ASN: 64600, RouterID: "10.0.0.1", GracefulShutdown: gs},
+	}
+	s, err := speaker.New(c, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve()
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.Stop()
+		cancel()
+	})
+	return s
+}
+
+// testPrefix returns a validated prefix with a readiness probe using the
+// given thresholds.
+func testPrefix(t *testing.T, ip string, failureThreshold, successThreshold int32, withdrawOnDown *bool, degraded *config.Degraded) config.Prefix {
+	t.Helper()
+	p := config.Prefix{
+		IPAddress: ip,
+		NextHop:   "10.0.0.1",
+		ReadinessProbe: &probe.Probe{
+			FailureThreshold: failureThreshold,
+			SuccessThreshold: successThreshold,
+			ProbeExec:        &probe.ProbeExec{Command: "true"},
+		},
+		WithdrawOnDown: withdrawOnDown,
+		Degraded:       degraded,
+	}
+	if degraded != nil {
+		p.DegradedProbe = &probe.Probe{FailureThreshold: 1, SuccessThreshold: 1, ProbeExec: &probe.ProbeExec{Command: "true"}}
+	}
+	c := &config.Config{
+		API:       config.ConfigAPI{ListenAddress: "127.0.0.1", ListenPort: 50051},
+		Speaker:   config.Speaker{ASN: 64600, RouterID: "10.0.0.1"},
+		Neighbors: []config.Neighbor{{Address: "10.0.0.2", ASN: 64599}},
+		Prefixes:  []config.Prefix{p},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return c.Prefixes[0]
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestAnnouncerApply(t *testing.T) {
+	degraded := &config.Degraded{MultiExitDescriminator: 1000}
+	tests := []struct {
+		name             string
+		failureThreshold int32
+		successThreshold int32
+		withdrawOnDown   *bool
+		degraded         *config.Degraded
+		// readiness and degradedResults are the results of the probes, in
+		// order; degraded probe results are recorded before readiness ones.
+		readiness       []bool
+		degradedResults []bool
+		routeCondition  *config.RouteCondition
+		want            speaker.State
+	}{
+		{
+			name:             "announced after the first success",
+			failureThreshold: 3, successThreshold: 1,
+			readiness: []bool{true},
+			want:      speaker.StateAnnounced,
+		},
+		{
+			name:             "not announced before success threshold",
+			failureThreshold: 3, successThreshold: 2,
+			readiness: []bool{true},
+			want:      speaker.StateWithdrawn,
+		},
+		{
+			name:             "announced at success threshold",
+			failureThreshold: 3, successThreshold: 2,
+			readiness: []bool{true, true},
+			want:      speaker.StateAnnounced,
+		},
+		{
+			name:             "never healthy stays withdrawn",
+			failureThreshold: 1, successThreshold: 1,
+			withdrawOnDown: boolPtr(false), degraded: degraded,
+			readiness: []bool{false, false},
+			want:      speaker.StateWithdrawn,
+		},
+		{
+			name:             "announced below failure threshold",
+			failureThreshold: 3, successThreshold: 1,
+			readiness: []bool{true, false, false},
+			want:      speaker.StateAnnounced,
+		},
+		{
+			name:             "withdrawn at failure threshold",
+			failureThreshold: 3, successThreshold: 1,
+			readiness: []bool{true, false, false, false},
+			want:      speaker.StateWithdrawn,
+		},
+		{
+			name:             "failures reset by a success",
+			failureThreshold: 2, successThreshold: 1,
+			readiness: []bool{true, false, true, false},
+			want:      speaker.StateAnnounced,
+		},
+		{
+			name:             "recovered at success threshold",
+			failureThreshold: 1, successThreshold: 2,
+			readiness: []bool{true, true, false, true, true},
+			want:      speaker.StateAnnounced,
+		},
+		{
+			name:             "still down below success threshold",
+			failureThreshold: 1, successThreshold: 2,
+			readiness: []bool{true, true, false, true},
+			want:      speaker.StateWithdrawn,
+		},
+		{
+			name:             "degraded without withdrawOnDown",
+			failureThreshold: 1, successThreshold: 1,
+			withdrawOnDown: boolPtr(false),
+			readiness:      []bool{true, false},
+			want:           speaker.StateDegraded,
+		},
+		{
+			name:             "degraded by default with degraded settings",
+			failureThreshold: 1, successThreshold: 1,
+			degraded:  degraded,
+			readiness: []bool{true, false, false},
+			want:      speaker.StateDegraded,
+		},
+		{
+			name:             "withdrawn with withdrawOnDown and degraded settings",
+			failureThreshold: 1, successThreshold: 1,
+			withdrawOnDown: boolPtr(true), degraded: degraded,
+			readiness: []bool{true, false},
+			want:      speaker.StateWithdrawn,
+		},
+		{
+			name:             "withdrawn at withdraw threshold",
+			failureThreshold: 1, successThreshold: 1,
+			degraded:  &config.Degraded{MultiExitDescriminator: 1000, WithdrawThreshold: 3},
+			readiness: []bool{true, false, false, false},
+			want:      speaker.StateWithdrawn,
+		},
+		{
+			name:             "degraded by the degraded probe",
+			failureThreshold: 1, successThreshold: 1,
+			degraded:        degraded,
+			degradedResults: []bool{false},
+			readiness:       []bool{true},
+			want:            speaker.StateDegraded,
+		},
+		{
+			name:             "withdrawn without the routes of the route condition",
+			failureThreshold: 1, successThreshold: 1,
+			routeCondition: &config.RouteCondition{Prefixes: []string{"0.0.0.0/0"}},
+			readiness:      []bool{true},
+			want:           speaker.StateWithdrawn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSpeaker(t, nil)
+			p := testPrefix(t, "192.0.2.1/32", tt.failureThreshold, tt.successThreshold, tt.withdrawOnDown, tt.degraded)
+			p.RouteCondition = tt.routeCondition
+			a := &announcer{prefix: p, speaker: s}
+			for _, ok := range tt.degradedResults {
+				a.degradedProbe(ok)
+			}
+			for _, ok := range tt.readiness {
+				a.readiness(ok)
+			}
+			if got := s.State(p.IPAddress).State; got != tt.want {
+				t.Errorf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnnouncerMaintenance(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "maintenance")
+	gs := &config.GracefulShutdown{Enabled: true, DrainPeriod: time.Hour, LocalPreference: 0}
+
+	tests := []struct {
+		name      string
+		readiness []bool
+		want      speaker.State
+	}{
+		{name: "announced prefix is drained", readiness: []bool{true, true}, want: speaker.StateDraining},
+		{name: "degraded prefix is drained", readiness: []bool{true, false}, want: speaker.StateDraining},
+		{name: "never healthy prefix stays withdrawn", readiness: []bool{false}, want: speaker.StateWithdrawn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSpeaker(t, gs)
+			p := testPrefix(t, "192.0.2.1/32", 1, 1, nil, &config.Degraded{MultiExitDescriminator: 1000})
+			p.Maintenance = file
+			a := &announcer{prefix: p, speaker: s}
+			for _, ok := range tt.readiness[:len(tt.readiness)-1] {
+				a.readiness(ok)
+			}
+			if err := os.WriteFile(file, nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { os.Remove(file) })
+			a.readiness(tt.readiness[len(tt.readiness)-1])
+			if got := s.State(p.IPAddress).State; got != tt.want {
+				t.Errorf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("withdrawn prefix is not drained", func(t *testing.T) {
+		s := newTestSpeaker(t, gs)
+		p := testPrefix(t, "192.0.2.1/32", 1, 1, nil, nil)
+		p.Maintenance = file
+		a := &announcer{prefix: p, speaker: s}
+		a.readiness(true)
+		a.readiness(false)
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Remove(file) })
+		a.readiness(true)
+		if got := s.State(p.IPAddress).State; got != speaker.StateWithdrawn {
+			t.Errorf("state = %s, want %s", got, speaker.StateWithdrawn)
+		}
+	})
+}
+
+func TestAnnouncerInherit(t *testing.T) {
+	s := newTestSpeaker(t, nil)
+	p := testPrefix(t, "192.0.2.1/32", 3, 3, nil, nil)
+	prev := &announcer{prefix: p, speaker: s}
+	for range 3 {
+		prev.readiness(true)
+	}
+	if got := s.State(p.IPAddress).State; got != speaker.StateAnnounced {
+		t.Fatalf("state = %s, want %s", got, speaker.StateAnnounced)
+	}
+
+	p.MultiExitDescriminator = 50
+	a := &announcer{prefix: p, speaker: s}
+	a.inherit(prev)
+	a.readiness(true)
+	if got := s.State(p.IPAddress).State; got != speaker.StateAnnounced {
+		t.Errorf("state after restart = %s, want %s", got, speaker.StateAnnounced)
+	}
+}
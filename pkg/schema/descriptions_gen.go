@@ -0,0 +1,184 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+package schema
+
+// descriptions holds the documentation of configuration types and fields,
+// keyed by "package.Type" and "package.Type.Field".
+var descriptions = map[string]string{
+	"config.BFDConfig":                               "The BFD agent detecting neighbor failures (RFC 5880).",
+	"config.BFDConfig.DetectionMultiplier":           "Number of missed packets before a session is declared down. Defaults to 3.",
+	"config.BFDConfig.Enabled":                       "Run BFD sessions with the neighbors.",
+	"config.BFDConfig.ListenAddress":                 "IP address BFD listens on. Defaults to 0.0.0.0.",
+	"config.BFDConfig.ListenPort":                    "UDP port BFD listens on. Defaults to 3784.",
+	"config.BFDConfig.MinimumReceptionInterval":      "Minimum interval between received BFD control packets. Defaults to 1s.",
+	"config.BFDConfig.MinimumTransmissionInterval":   "Minimum interval between transmitted BFD control packets. Defaults to 1s.",
+	"config.BFDConfig.Passive":                       "Wait for the neighbor to start the BFD session.",
+	"config.BMPConfig":                               "Exports the BGP sessions and routes of the speaker to a BMP collector (RFC 7854).",
+	"config.BMPConfig.Address":                       "IP address or host name of the collector.",
+	"config.BMPConfig.Enabled":                       "Connect to the collector.",
+	"config.BMPConfig.Port":                          "TCP port of the collector. Defaults to 11019.",
+	"config.BMPConfig.RouteMonitoringPolicy":         "Routes reported: pre-policy, post-policy or both for the routes received from neighbors, local-rib for the best routes, including the prefixes announced by herald (RFC 9069), or all. Defaults to local-rib.",
+	"config.BMPConfig.StatisticsInterval":            "Interval between statistics reports, in whole seconds. 0 disables them.",
+	"config.BMPConfig.SysName":                       "Name the speaker reports to the collector. Defaults to the host name.",
+	"config.Config":                                  "The herald configuration file.",
+	"config.Config.Checks":                           "Named probes that prefixes reference with \"check: <name>\". Prefixes using the same unmodified check share a single execution per period.",
+	"config.Config.DynamicNeighbors":                 "Ranges herald accepts sessions from, with the settings of a peer group. Requires speaker.listenPort.",
+	"config.Config.Include":                          "Glob patterns of drop-in files contributing neighbors and prefixes, relative to the directory of this file (e.g. \"conf.d/*.yaml\").",
+	"config.Config.Neighbors":                        "BGP peers every prefix is announced to.",
+	"config.Config.PeerGroups":                       "Named partial neighbors that neighbors reference with \"peerGroup: <name>\" and override field by field. Dynamic neighbors take all their settings from their peer group.",
+	"config.Config.PrefixTemplates":                  "Named partial prefixes that prefixes reference with \"template: <name>\" and override field by field.",
+	"config.Config.Prefixes":                         "Prefixes announced while their readiness probe succeeds.",
+	"config.ConfigAPI":                               "The GoBGP gRPC API server.",
+	"config.ConfigAPI.ListenAddress":                 "IP address the gRPC API listens on.",
+	"config.ConfigAPI.ListenPort":                    "TCP port the gRPC API listens on.",
+	"config.Degraded":                                "Holds the attributes of a prefix announced in degraded mode, to keep attracting some traffic instead of withdrawing it.",
+	"config.Degraded.AsPathPrepend":                  "AS numbers prepended in front of the prefix asPathPrepend while degraded.",
+	"config.Degraded.Communities":                    "Standard communities added to the prefix ones while degraded.",
+	"config.Degraded.LargeCommunities":               "Large communities added to the prefix ones while degraded.",
+	"config.Degraded.MultiExitDescriminator":         "MULTI_EXIT_DISC attribute replacing the prefix one while degraded.",
+	"config.Degraded.WithdrawThreshold":              "Consecutive readiness probe failures after which the prefix is withdrawn. Below it, the prefix stays announced degraded. 0 never withdraws it. Requires withdrawOnDown false.",
+	"config.DynamicNeighbor":                         "A range of addresses herald accepts BGP sessions from.",
+	"config.DynamicNeighbor.PeerGroup":               "Peer group whose settings apply to the sessions from the range.",
+	"config.DynamicNeighbor.Prefix":                  "Range in CIDR notation (e.g. 198.51.100.0/24).",
+	"config.Fragment":                                "A drop-in file matched by Config.Include. It can only contribute neighbors and prefixes.",
+	"config.GracefulShutdown":                        "Drains traffic away from prefixes before withdrawing them by announcing them with the GRACEFUL_SHUTDOWN community (RFC 8326).",
+	"config.GracefulShutdown.DrainPeriod":            "Time left to neighbors to move traffic elsewhere before prefixes are withdrawn. Defaults to 30s.",
+	"config.GracefulShutdown.Enabled":                "Drain prefixes on SIGTERM and when their maintenance file appears.",
+	"config.GracefulShutdown.LocalPreference":        "LOCAL_PREF attribute sent to iBGP neighbors while draining. Defaults to 0.",
+	"config.LinkBandwidth":                           "The link bandwidth extended community of a prefix (draft-ietf-idr-link-bandwidth), used by routers for weighted ECMP.",
+	"config.LinkBandwidth.ASN":                       "AS number of the community. Defaults to speaker.asn, or 23456 (AS_TRANS) when it does not fit in 16 bits.",
+	"config.LinkBandwidth.Bandwidth":                 "Bandwidth in bits per second, with an optional k, M, G or T suffix. Announced until the bandwidth probe first succeeds.",
+	"config.LinkBandwidth.Hysteresis":                "Change of the probed bandwidth, in percent of the announced one, below which the prefix is not announced again. Defaults to 0, announcing every change.",
+	"config.MRTConfig":                               "Dumps BGP messages and routing tables to MRT files (RFC 6396) for later analysis.",
+	"config.MRTConfig.Enabled":                       "Write the dumps.",
+	"config.MRTConfig.Table":                         "Snapshots of the routing table, including the prefixes announced by herald, taken at each rotation.",
+	"config.MRTConfig.Updates":                       "UPDATE messages received from neighbors.",
+	"config.MRTDump":                                 "A series of MRT files.",
+	"config.MRTDump.FileName":                        "Path of the files, a Go time layout expanded when a file is created, e.g. /var/lib/herald/mrt/updates.20060102.1504.mrt.",
+	"config.MRTDump.RotationInterval":                "Interval between files, in whole seconds, at least 1m. Defaults to 1h.",
+	"config.MetricsConfig":                           "The Prometheus metrics endpoint.",
+	"config.MetricsConfig.Enabled":                   "Serve Prometheus metrics on /metrics.",
+	"config.MetricsConfig.Interval":                  "How often BGP metrics are collected from GoBGP. Defaults to 15s.",
+	"config.MetricsConfig.ListenAddress":             "IP address the metrics server listens on. Defaults to 127.0.0.1.",
+	"config.MetricsConfig.ListenPort":                "TCP port the metrics server listens on. Defaults to 9091.",
+	"config.Neighbor":                                "A BGP peer.",
+	"config.Neighbor.ASN":                            "Autonomous system number of the peer.",
+	"config.Neighbor.Address":                        "IP address of the peer.",
+	"config.Neighbor.ConnectRetry":                   "Interval between connection attempts. Defaults to 120s.",
+	"config.Neighbor.EbgpMultihopEnabled":            "Allow eBGP sessions with peers that are not directly connected.",
+	"config.Neighbor.EbgpMultihopTTL":                "TTL of packets sent to a multihop peer. Defaults to 255.",
+	"config.Neighbor.Families":                       "Address families negotiated with the peer: ipv4-unicast and ipv6-unicast. Defaults to the family of address, or of the first range of a peer group.",
+	"config.Neighbor.HoldTime":                       "Hold time proposed to the peer, at least 3s. Defaults to 90s.",
+	"config.Neighbor.KeepaliveInterval":              "Interval between keepalive messages. Defaults to a third of holdTime, rounded down to whole seconds.",
+	"config.Neighbor.LocalAddress":                   "Source address of the BGP session. Chosen by the kernel when unset.",
+	"config.Neighbor.Passive":                        "Wait for the peer to connect instead of connecting to it. Requires speaker.listenPort.",
+	"config.Neighbor.Password":                       "TCP MD5 authentication password (RFC 2385), inline or read from a file with valueFrom.",
+	"config.Neighbor.PeerGroup":                      "Name of the peer group this neighbor belongs to and inherits from.",
+	"config.Neighbor.TTLMin":                         "Minimum TTL accepted when ttlSecurityEnabled is set. Defaults to 255, for directly connected peers.",
+	"config.Neighbor.TTLSecurityEnabled":             "Drop packets from the peer received with a TTL below ttlMin (GTSM, RFC 5082).",
+	"config.NeighborOverride":                        "Changes the attributes of a prefix sent to some neighbors. The first override listing a neighbor applies.",
+	"config.NeighborOverride.AsPathPrepend":          "AS number prepended to the AS path, repeated as many times as listed.",
+	"config.NeighborOverride.Communities":            "Standard communities added to the prefix ones.",
+	"config.NeighborOverride.LargeCommunities":       "Large communities added to the prefix ones.",
+	"config.NeighborOverride.MultiExitDescriminator": "MULTI_EXIT_DISC attribute replacing the prefix one.",
+	"config.NeighborOverride.Neighbors":              "Addresses of the neighbors and names of the peer groups the override applies to.",
+	"config.Prefix":                                  "An announced prefix and the health checks controlling it.",
+	"config.Prefix.ASN":                              "AS number the route appears to originate from, last in the AS path. Ignored when equal to speaker.asn.",
+	"config.Prefix.AsPathPrepend":                    "AS numbers prepended to the AS path, before asn. The speaker AS number is added in front of them for eBGP neighbors.",
+	"config.Prefix.BandwidthProbe":                   "Probe whose output is the bandwidth announced in linkBandwidth, e.g. the free capacity of the service. Requires linkBandwidth.",
+	"config.Prefix.Communities":                      "Standard communities attached to the route, as ASN:value, a 32-bit number or a well-known name such as no-export or graceful-shutdown.",
+	"config.Prefix.Degraded":                         "Attributes the prefix is announced with while degraded, i.e. when the degraded probe fails, or when the readiness probe fails without withdrawOnDown and fewer times than the withdraw threshold.",
+	"config.Prefix.DegradedProbe":                    "Probe announcing the prefix with the degraded attributes on failure. Requires degraded.",
+	"config.Prefix.ExtendedCommunities":              "Extended communities attached to the route, as rt:ADMIN:VALUE or soo:ADMIN:VALUE where ADMIN is an AS number or an IPv4 address.",
+	"config.Prefix.IPAddress":                        "Announced prefix in CIDR notation (e.g. 192.0.2.1/32).",
+	"config.Prefix.Interface":                        "Local interface ipAddress is added to before the prefix is announced, e.g. lo. The prefix is not announced when the address cannot be added. Linux only.",
+	"config.Prefix.KeepAddress":                      "Keep ipAddress on interface after the prefix is withdrawn and when herald stops.",
+	"config.Prefix.LargeCommunities":                 "Large communities attached to the route, as ASN:function:parameter.",
+	"config.Prefix.LinkBandwidth":                    "Link bandwidth extended community announced with the prefix so that routers balance traffic between nodes by weight.",
+	"config.Prefix.LivenessProbe":                    "Probe restarting the service when it fails.",
+	"config.Prefix.LocalPreference":                  "LOCAL_PREF attribute sent to iBGP neighbors. GoBGP sends 100 when 0.",
+	"config.Prefix.Maintenance":                      "Path of a file whose presence, checked at each readiness probe, puts the prefix in maintenance: it is drained when speaker.gracefulShutdown is enabled, then withdrawn.",
+	"config.Prefix.MinEstablishedPeers":              "Number of established sessions with the neighbors of the prefix below which probe results are not acted upon, leaving the prefix as it is.",
+	"config.Prefix.MultiExitDescriminator":           "MULTI_EXIT_DISC attribute of the route, not sent when 0.",
+	"config.Prefix.Name":                             "Name used in logs and metrics. Defaults to ipAddress.",
+	"config.Prefix.NeighborOverrides":                "Attributes changed for the routes sent to some neighbors.",
+	"config.Prefix.Neighbors":                        "Addresses of the neighbors and names of the peer groups the prefix is announced to. Defaults to every neighbor.",
+	"config.Prefix.NextHop":                          "Next hop of the route, in the address family of ipAddress. Defaults to the primary address of nextHopInterface, or else to the local address of the BGP session with each neighbor (next-hop-self).",
+	"config.Prefix.NextHopInterface":                 "Interface whose primary address in the address family of ipAddress is the next hop, resolved at each announcement.",
+	"config.Prefix.NextHopLinkLocal":                 "Optional IPv6 link-local next hop sent along with nextHop for IPv6 prefixes.",
+	"config.Prefix.Origin":                           "ORIGIN attribute of the route: igp, egp or incomplete. Defaults to igp.",
+	"config.Prefix.ReadinessProbe":                   "Probe announcing the prefix on success and withdrawing it on failure.",
+	"config.Prefix.RouteCondition":                   "Routes that must be received for the prefix to be announced, e.g. a default route from the upstream routers.",
+	"config.Prefix.Service":                          "Service checked before probing and restarted by the liveness probe.",
+	"config.Prefix.StartupProbe":                     "Probe run once before the others start.",
+	"config.Prefix.Template":                         "Name of the prefix template this prefix is based on.",
+	"config.Prefix.WithdrawOnDown":                   "Withdraw the route when the readiness probe fails failureThreshold times in a row, instead of announcing it degraded. Defaults to true without degraded and to false with it.",
+	"config.Reconciliation":                          "Periodically compares the Adj-RIB-Out of each neighbor with the prefixes herald announced and announces missing ones again.",
+	"config.Reconciliation.Enabled":                  "Run the reconciliation.",
+	"config.Reconciliation.Interval":                 "Interval between reconciliations. Defaults to 1m.",
+	"config.RouteCondition":                          "Withdraws a prefix while none of some routes is received, so that a node that lost its upstream connectivity stops attracting traffic.",
+	"config.RouteCondition.Neighbors":                "Addresses of the neighbors and names of the peer groups the routes must be received from. Defaults to every neighbor.",
+	"config.RouteCondition.Prefixes":                 "Prefixes of the routes, in CIDR notation. At least one must be received.",
+	"config.Speaker":                                 "The local BGP speaker.",
+	"config.Speaker.ASN":                             "Local autonomous system number.",
+	"config.Speaker.GracefulRestartEnabled":          "Advertise the graceful restart capability (RFC 4724).",
+	"config.Speaker.GracefulRestartRestartTime":      "Restart time advertised to neighbors, in seconds (at most 4095).",
+	"config.Speaker.GracefulShutdown":                "Drain traffic before withdrawing prefixes on shutdown and maintenance.",
+	"config.Speaker.ListenAddresses":                 "Addresses accepting BGP connections when listenPort is set. Defaults to all addresses.",
+	"config.Speaker.ListenPort":                      "TCP port accepting BGP connections, needed by passive neighbors. Herald does not listen when unset.",
+	"config.Speaker.Reconciliation":                  "Check that announced prefixes reached the neighbors.",
+	"config.Speaker.RouterID":                        "BGP router ID, an IPv4 address.",
+	"logger.Config":                                  "Holds the logging configuration",
+	"logger.Config.Driver":                           "Log destination: syslog, journald, file, windows or none. Defaults to file.",
+	"logger.Config.File":                             "Log file path, used when driver is \"file\". Defaults to herald.log.",
+	"logger.Config.Format":                           "Log format: json or text. Defaults to json.",
+	"logger.Config.Level":                            "Minimum level: debug, info, warn or error. Defaults to info.",
+	"probe.GRPCMetadata":                             "A metadata entry sent with gRPC health checks.",
+	"probe.GRPCMetadata.Name":                        "Metadata key.",
+	"probe.GRPCMetadata.Value":                       "Metadata value.",
+	"probe.GRPCMetadata.ValueFrom":                   "Source of the metadata value, instead of value.",
+	"probe.HTTPHeader":                               "A header sent with HTTP probes.",
+	"probe.HTTPHeader.Name":                          "Header name.",
+	"probe.HTTPHeader.Value":                         "Header value.",
+	"probe.HTTPHeader.ValueFrom":                     "Source of the header value, instead of value.",
+	"probe.Probe":                                    "A health check run periodically, with exactly one of http, grpc, exec or tcp set.",
+	"probe.Probe.Check":                              "Name of the shared check this probe is based on. Prefixes referencing the same check without overriding any field share its executions.",
+	"probe.Probe.FailureThreshold":                   "Minimum consecutive failures for the probe to be considered failed after having succeeded. Defaults to 3. Minimum value is 1.",
+	"probe.Probe.InitialDelaySeconds":                "Number of seconds after the service has started before liveness probes are initiated",
+	"probe.Probe.PeriodSeconds":                      "How often (in seconds) to perform the probe. Default to 10 seconds. Minimum value is 1.",
+	"probe.Probe.SuccessThreshold":                   "Minimum consecutive successes for the probe to be considered successful after having failed. Defaults to 1. Must be 1 for liveness and startup. Minimum value is 1.",
+	"probe.Probe.TerminationGracePeriodSeconds":      "Optional duration in seconds the service needs to terminate gracefully upon probe failure. Used by the scheduler to wait before forcefully terminating/restarting a failed service.",
+	"probe.Probe.TimeoutSeconds":                     "Number of seconds after which the probe times out. Defaults to 1 second. Minimum value is 1. Applied as a context timeout for the entire probe operation.",
+	"probe.ProbeExec":                                "Runs a command.",
+	"probe.ProbeExec.Args":                           "Arguments of the command.",
+	"probe.ProbeExec.Command":                        "Command to run.",
+	"probe.ProbeExec.ExitCodes":                      "Exit codes considered successful. Defaults to [0].",
+	"probe.ProbeExec.Timeout":                        "Command timeout.",
+	"probe.ProbeExec.User":                           "User to run the command as.",
+	"probe.ProbeGRPC":                                "Uses the gRPC health checking protocol.",
+	"probe.ProbeGRPC.Host":                           "Target host. Defaults to localhost.",
+	"probe.ProbeGRPC.Metadata":                       "Metadata sent with the request.",
+	"probe.ProbeGRPC.Port":                           "Target port.",
+	"probe.ProbeGRPC.Service":                        "Service name sent in the health check request.",
+	"probe.ProbeGRPC.Timeout":                        "Request timeout. Defaults to 1s.",
+	"probe.ProbeHTTP":                                "Performs an HTTP GET request.",
+	"probe.ProbeHTTP.ExpectedStatus":                 "Status codes considered successful. Defaults to [200].",
+	"probe.ProbeHTTP.HTTPHeaders":                    "Headers sent with the request.",
+	"probe.ProbeHTTP.Host":                           "Target host. Defaults to localhost.",
+	"probe.ProbeHTTP.Path":                           "Request path. Defaults to /.",
+	"probe.ProbeHTTP.Port":                           "Target port.",
+	"probe.ProbeHTTP.RequestTimeout":                 "Request timeout. Defaults to 1s.",
+	"probe.ProbeHTTP.Scheme":                         "http or https. Defaults to http.",
+	"probe.ProbeStatus.Output":                       "Output of exec probes and response body of HTTP probes, truncated to MaxOutputSize bytes.",
+	"probe.ProbeTCP":                                 "Opens a TCP connection.",
+	"probe.ProbeTCP.Host":                            "Target host. Defaults to localhost.",
+	"probe.ProbeTCP.Port":                            "Target port.",
+	"probe.ProbeTCP.Timeout":                         "Connection timeout. Defaults to 1s.",
+	"secret.Source":                                  "References a sensitive value stored outside of the configuration file, e.g. a file written by a secret manager.",
+	"secret.Source.File":                             "Path of a file holding the value. A single trailing newline is removed.",
+	"secret.Value":                                   "A sensitive value set inline as a string, or read from a file with valueFrom like the values of probe headers. It is printed and marshaled as Redacted.",
+	"secret.Value.ValueFrom":                         "Source the value is read from instead of being set inline.",
+	"service.Service":                                "A unit managed by the service manager.",
+	"service.Service.Name":                           "Unit name (e.g. nginx.service).",
+	"service.Service.Type":                           "Service manager. Only systemd is supported. Defaults to systemd.",
+}
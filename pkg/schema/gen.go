@@ -0,0 +1,121 @@
+//go:build ignore
+
+// gen extracts the documentation of the configuration types into
+// descriptions_gen.go, as it is not available through reflection.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// packages holding the types of the configuration file.
+var packages = []string{"config", "logger", "probe", "secret", "service"}
+
+func main() {
+	descriptions := map[string]string{}
+	for _, pkg := range packages {
+		fset := token.NewFileSet()
+		files, err := filepath.Glob(filepath.Join("..", pkg, "*.go"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+			if err != nil {
+				log.Fatal(err)
+			}
+			collect(pkg, f, descriptions)
+		}
+	}
+
+	keys := make([]string, 0, len(descriptions))
+	for k := range descriptions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen.go; DO NOT EDIT.\n\npackage schema\n\n")
+	b.WriteString("// descriptions holds the documentation of configuration types and fields,\n")
+	b.WriteString("// keyed by \"package.Type\" and \"package.Type.Field\".\n")
+	b.WriteString("var descriptions = map[string]string{\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\t%q: %q,\n", k, descriptions[k])
+	}
+	b.WriteString("}\n")
+
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("descriptions_gen.go", out, 0o600); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func collect(pkg string, f *ast.File, descriptions map[string]string) {
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || !ts.Name.IsExported() {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			if text := clean(doc); text != "" {
+				descriptions[pkg+"."+ts.Name.Name] = typeDescription(ts.Name.Name, text)
+			}
+			for _, field := range st.Fields.List {
+				text := clean(field.Doc)
+				if text == "" {
+					text = clean(field.Comment)
+				}
+				if text == "" {
+					continue
+				}
+				for _, name := range field.Names {
+					descriptions[pkg+"."+ts.Name.Name+"."+name.Name] = text
+				}
+			}
+		}
+	}
+}
+
+// typeDescription removes the type name starting a type documentation, so
+// "Speaker is the local BGP speaker." becomes "The local BGP speaker.".
+func typeDescription(name, text string) string {
+	text = strings.TrimPrefix(text, name+" is ")
+	text = strings.TrimPrefix(text, name+" ")
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+// clean returns the text of a comment on a single line, without directives.
+func clean(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(cg.Text()), " ")
+}
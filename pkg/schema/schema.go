@@ -0,0 +1,187 @@
+// Package schema generates a JSON Schema of the herald configuration file
+// for editors and CI.
+package schema
+
+//go:generate go run gen.go
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+	"github.com/ahmet2mir/herald/pkg/secret"
+)
+
+// Schema is a JSON Schema (draft 2020-12) document or subschema.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *uint64            `json:"minimum,omitempty"`
+	Maximum              *uint64            `json:"maximum,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// interpolationRef matches "${VAR}" references, accepted for every scalar
+// since they are replaced before the configuration is decoded.
+const interpolationRef = "#/$defs/interpolation"
+
+// durationPattern matches the durations accepted by time.ParseDuration.
+const durationPattern = `^-?([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$|^0$`
+
+// enums lists the accepted values of string fields, keyed like descriptions.
+var enums = map[string][]string{
+	"logger.Config.Driver":   {"syslog", "journald", "file", "windows", "none"},
+	"logger.Config.Format":   {"json", "text"},
+	"logger.Config.Level":    {"debug", "info", "warn", "error", "dpanic", "panic", "fatal"},
+	"probe.ProbeHTTP.Scheme": {"http", "https"},
+	"service.Service.Type":   {"systemd"},
+}
+
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	secretValueType = reflect.TypeOf(secret.Value{})
+)
+
+// Generate returns the JSON Schema of the herald configuration file.
+func Generate() *Schema {
+	g := &generator{defs: map[string]*Schema{
+		"interpolation": {
+			Description: "Environment variable reference, replaced before the configuration is decoded.",
+			Type:        "string",
+			Pattern:     `\$\{[A-Za-z_][A-Za-z0-9_]*(:-[^}]*)?\}`,
+		},
+	}}
+	root := g.object(reflect.TypeOf(config.Config{}))
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	root.ID = "https://github.com/ahmet2mir/herald/config.schema.json"
+	root.Title = "herald configuration"
+	root.Defs = g.defs
+	return root
+}
+
+// JSON returns the indented JSON encoding of the schema.
+func (s *Schema) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type generator struct {
+	defs map[string]*Schema
+}
+
+// typeName returns the key of t in descriptions, e.g. "config.Neighbor".
+func typeName(t reflect.Type) string {
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+// schema returns the schema of a value of type t, described by the
+// documentation of field key when it has one.
+func (g *generator) schema(t reflect.Type, key string) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var s *Schema
+	switch {
+	case t == durationType:
+		s = scalar(&Schema{Type: "string", Pattern: durationPattern, Description: "Duration such as 500ms, 10s or 1m30s."})
+	case t == secretValueType:
+		// Set inline or as a mapping with valueFrom, see secret.Value.
+		s = &Schema{AnyOf: []*Schema{{Type: "string"}, {Ref: interpolationRef}, g.object(t)}}
+	case t.Kind() == reflect.Struct:
+		name := typeName(t)
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // Reserve the name for recursive types.
+			g.defs[name] = g.object(t)
+		}
+		s = &Schema{Ref: "#/$defs/" + name}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		s = &Schema{Type: "array", Items: g.schema(t.Elem(), "")}
+		if values, ok := enums[key]; ok {
+			s.Items = scalar(&Schema{Type: "string", Enum: values})
+		}
+	case t.Kind() == reflect.Map:
+		s = &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem(), "")}
+	case t.Kind() == reflect.String:
+		s = &Schema{Type: "string"}
+		if values, ok := enums[key]; ok {
+			s = scalar(&Schema{Type: "string", Enum: values})
+		}
+	case t.Kind() == reflect.Bool:
+		s = scalar(&Schema{Type: "boolean"})
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		s = scalar(&Schema{Type: "integer"})
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		min, max := uint64(0), uint64(1)<<t.Bits()-1
+		s = scalar(&Schema{Type: "integer", Minimum: &min, Maximum: &max})
+	default:
+		s = &Schema{}
+	}
+
+	if d, ok := descriptions[key]; ok {
+		s.Description = d
+	}
+	return s
+}
+
+// scalar accepts s or an environment variable reference.
+func scalar(s *Schema) *Schema {
+	description := s.Description
+	s.Description = ""
+	return &Schema{Description: description, AnyOf: []*Schema{s, {Ref: interpolationRef}}}
+}
+
+// object returns the schema of struct t, following the naming rules of
+// gopkg.in/yaml.v3 and rejecting unknown fields like herald does.
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Description:          descriptions[typeName(t)],
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+	}
+	g.fields(t, s.Properties)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, properties map[string]*Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if strings.Contains(","+opts+",", ",inline,") {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			g.fields(ft, properties)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		properties[name] = g.schema(f.Type, typeName(t)+"."+f.Name)
+	}
+}
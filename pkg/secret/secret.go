@@ -0,0 +1,121 @@
+package secret
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces sensitive values when they are printed or marshaled.
+const Redacted = "<redacted>"
+
+// String is a sensitive configuration value such as a password or a token.
+// It is printed, logged and marshaled as Redacted; use Reveal to read it.
+type String string
+
+func (s String) String() string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// GoString keeps %#v from printing the value.
+func (s String) GoString() string {
+	return fmt.Sprintf("secret.String(%q)", s.String())
+}
+
+func (s String) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+func (s String) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", s.String())), nil
+}
+
+func (s String) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Reveal returns the actual value.
+func (s String) Reveal() string {
+	return string(s)
+}
+
+// Source references a sensitive value stored outside of the configuration
+// file, e.g. a file written by a secret manager.
+type Source struct {
+	// Path of a file holding the value. A single trailing newline is removed.
+	File string `yaml:"file"`
+}
+
+// Resolve reads the value referenced by s.
+func (s *Source) Resolve() (String, error) {
+	if s.File == "" {
+		return "", fmt.Errorf("file is required")
+	}
+	data, err := os.ReadFile(filepath.Clean(s.File))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	value = strings.TrimSuffix(value, "\r")
+	return String(value), nil
+}
+
+// Resolve sets value from the source from, if any. A value set both inline
+// and from a source is rejected; resolving twice is allowed.
+func Resolve(value *String, from *Source) error {
+	if from == nil {
+		return nil
+	}
+	v, err := from.Resolve()
+	if err != nil {
+		return err
+	}
+	if *value != "" && *value != v {
+		return fmt.Errorf("an inline value and a source are mutually exclusive")
+	}
+	*value = v
+	return nil
+}
+
+// Value is a sensitive value set inline as a string, or read from a file
+// with valueFrom like the values of probe headers. It is printed and
+// marshaled as Redacted.
+type Value struct {
+	String `yaml:"-"`
+	// Source the value is read from instead of being set inline.
+	ValueFrom *Source `yaml:"valueFrom"`
+}
+
+// UnmarshalYAML decodes a string or a mapping with valueFrom.
+func (v *Value) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		return n.Decode(&v.String)
+	}
+	var from struct {
+		ValueFrom *Source `yaml:"valueFrom"`
+	}
+	if err := n.Decode(&from); err != nil {
+		return err
+	}
+	v.ValueFrom = from.ValueFrom
+	return nil
+}
+
+// MarshalYAML keeps the source of a value not resolved yet.
+func (v Value) MarshalYAML() (any, error) {
+	if v.String == "" && v.ValueFrom != nil {
+		return map[string]*Source{"valueFrom": v.ValueFrom}, nil
+	}
+	return v.String.MarshalYAML()
+}
+
+// Resolve reads the value from its valueFrom source, if any.
+func (v *Value) Resolve() error {
+	return Resolve(&v.String, v.ValueFrom)
+}
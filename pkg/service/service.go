@@ -6,10 +6,15 @@ import (
 
 	"github.com/coreos/go-systemd/v22/dbus"
 	"go.uber.org/zap"
+
+	"github.com/ahmet2mir/herald/pkg/validation"
 )
 
+// Service is a unit managed by the service manager.
 type Service struct {
+	// Unit name (e.g. nginx.service).
 	Name string
+	// Service manager. Only systemd is supported. Defaults to systemd.
 	Type string
 }
 
@@ -21,7 +26,12 @@ func (s *Service) Status() error {
 	return nil
 }
 
+// Started reports whether the unit is known to systemd. A nil Service, for
+// prefixes without a service, is always considered started.
 func (s *Service) Started(ctx context.Context) (bool, error) {
+	if s == nil {
+		return true, nil
+	}
 	conn, err := dbus.NewSystemConnectionContext(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to connect to systemd: %w", err)
@@ -39,6 +49,9 @@ func (s *Service) Started(ctx context.Context) (bool, error) {
 }
 
 func (s *Service) Restart(ctx context.Context) (bool, error) {
+	if s == nil {
+		return false, fmt.Errorf("no service configured")
+	}
 	conn, err := dbus.NewSystemConnectionContext(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to connect to systemd: %w", err)
@@ -52,3 +65,17 @@ func (s *Service) Restart(ctx context.Context) (bool, error) {
 
 	return true, nil
 }
+
+// Validate fills the default type and records invalid fields of the service
+// found at path.
+func (s *Service) Validate(path string, errs *validation.Errors) {
+	if s.Type == "" {
+		s.Type = "systemd"
+	}
+	if s.Name == "" {
+		errs.Addf(validation.Field(path, "name"), "is required")
+	}
+	if s.Type != "systemd" {
+		errs.Addf(validation.Field(path, "type"), "unsupported service type %q, only systemd is supported", s.Type)
+	}
+}
@@ -0,0 +1,106 @@
+package speaker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	api "github.com/osrg/gobgp/v3/api"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/ahmet2mir/herald/pkg/community"
+	"github.com/ahmet2mir/herald/pkg/config"
+)
+
+// DrainPath announces p with the GRACEFUL_SHUTDOWN community and the drain
+// LOCAL_PREF so that neighbors move its traffic elsewhere (RFC 8326), and
+// with its degraded attributes when degraded is set.
+func (s *Speaker) DrainPath(p config.Prefix, degraded bool) error {
+	attrs := p
+	if degraded {
+		attrs = p.DegradedPrefix()
+	}
+	path, err := s.anycastPath(attrs)
+	if err != nil {
+		return err
+	}
+	if err := s.drainAttributes(path); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.announce(p, StateDraining, path)
+}
+
+// Drain announces every announced prefix again with the GRACEFUL_SHUTDOWN
+// community and the drain LOCAL_PREF, keeping their other attributes.
+func (s *Speaker) Drain() error {
+	zap.S().Warn("Draining prefixes")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.announcements {
+		if a.State == StateWithdrawn {
+			continue
+		}
+		path := proto.Clone(a.path).(*api.Path)
+		if err := s.drainAttributes(path); err != nil {
+			return err
+		}
+		if err := s.announce(a.prefix, StateDraining, path); err != nil {
+			return fmt.Errorf("drain %s: %w", a.prefix.IPAddress, err)
+		}
+	}
+	return nil
+}
+
+// WithdrawAll withdraws every announced prefix.
+func (s *Speaker) WithdrawAll() error {
+	zap.S().Warn("Withdrawing all prefixes")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for _, a := range s.announcements {
+		if err := s.withdraw(a.prefix); err != nil {
+			errs = append(errs, fmt.Errorf("withdraw %s: %w", a.prefix.IPAddress, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// drainAttributes adds the GRACEFUL_SHUTDOWN community to path and replaces
+// its LOCAL_PREF, which GoBGP only sends to iBGP neighbors.
+func (s *Speaker) drainAttributes(path *api.Path) error {
+	var localPref uint32
+	if gs := s.Config().Speaker.GracefulShutdown; gs != nil {
+		localPref = gs.LocalPreference
+	}
+
+	communities := &api.CommunitiesAttribute{}
+	attrs := make([]*anypb.Any, 0, len(path.Pattrs)+2)
+	for _, attr := range path.Pattrs {
+		switch {
+		case attr.MessageIs(communities):
+			if err := attr.UnmarshalTo(communities); err != nil {
+				return fmt.Errorf("error %T %w", communities, err)
+			}
+		case attr.MessageIs(&api.LocalPrefAttribute{}):
+		default:
+			attrs = append(attrs, attr)
+		}
+	}
+	if !slices.Contains(communities.Communities, community.GracefulShutdown) {
+		communities.Communities = append(communities.Communities, community.GracefulShutdown)
+	}
+
+	for _, m := range []proto.Message{communities, &api.LocalPrefAttribute{LocalPref: localPref}} {
+		attr, err := anypb.New(m)
+		if err != nil {
+			return fmt.Errorf("error %T %w", m, err)
+		}
+		attrs = append(attrs, attr)
+	}
+	path.Pattrs = attrs
+	return nil
+}
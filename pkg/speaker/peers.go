@@ -0,0 +1,263 @@
+package speaker
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/osrg/gobgp/v3/api"
+	gobgplog "github.com/osrg/gobgp/v3/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+	"github.com/ahmet2mir/herald/pkg/metrics"
+)
+
+// PeerEvent is a change of the session state of a neighbor.
+type PeerEvent struct {
+	Time    time.Time `json:"time"`
+	Address string    `json:"address"`
+	ASN     uint32    `json:"asn"`
+	State   string    `json:"state"`
+	// Previous is empty in the events describing the current state of the
+	// neighbors when a subscription starts.
+	Previous string `json:"previousState,omitempty"`
+	// Reason is why an established session went down, e.g.
+	// hold-timer-expired or notification-received followed by the error
+	// code and subcode.
+	Reason string `json:"reason,omitempty"`
+	// EstablishedPeers is the number of established sessions after the
+	// change.
+	EstablishedPeers int `json:"establishedPeers"`
+}
+
+// peer is the last known session state of a neighbor.
+type peer struct {
+	asn   uint32
+	state api.PeerState_SessionState
+	since time.Time
+}
+
+// eventBuffer is the number of events buffered for each subscriber before
+// new ones are dropped.
+const eventBuffer = 64
+
+// peerLogger records the reason GoBGP logs when an established session goes
+// down, which neither its peer events nor ListPeer carry. It depends on the
+// "Peer Down" message and its Key and Reason fields, unchanged up to GoBGP
+// v3.36.0 and checked by TestPeerDownReason.
+type peerLogger struct {
+	gobgplog.Logger
+	speaker *Speaker
+}
+
+func (l *peerLogger) Info(msg string, fields gobgplog.Fields) {
+	if msg == "Peer Down" {
+		address, _ := fields["Key"].(string)
+		reason, _ := fields["Reason"].(string)
+		l.speaker.peersMu.Lock()
+		l.speaker.downReasons[peerKey(address)] = reason
+		l.speaker.peersMu.Unlock()
+	}
+	l.Logger.Info(msg, fields)
+}
+
+// peerKey normalizes the address of a neighbor.
+func peerKey(address string) string {
+	if ip := net.ParseIP(address); ip != nil {
+		return ip.String()
+	}
+	return address
+}
+
+// watchPeers follows the session state changes of the neighbors until the
+// speaker context is cancelled.
+func (s *Speaker) watchPeers() error {
+	return s.Server.WatchEvent(s.Context, &api.WatchEventRequest{Peer: &api.WatchEventRequest_Peer{}}, func(r *api.WatchEventResponse) {
+		e := r.GetPeer()
+		if e == nil || e.Type != api.WatchEventResponse_PeerEvent_STATE || e.Peer.GetState() == nil {
+			return
+		}
+		s.peerChanged(e.Peer.State.NeighborAddress, e.Peer.State.PeerAsn, e.Peer.State.SessionState)
+	})
+}
+
+// peerChanged records the new state of a neighbor, then logs and publishes
+// the change.
+func (s *Speaker) peerChanged(address string, asn uint32, state api.PeerState_SessionState) {
+	s.peersMu.Lock()
+	key := peerKey(address)
+	p, ok := s.peers[key]
+	if !ok {
+		p = &peer{state: api.PeerState_UNKNOWN}
+		s.peers[key] = p
+	}
+	if p.state == state {
+		s.peersMu.Unlock()
+		return
+	}
+	event := PeerEvent{
+		Time:     time.Now(),
+		Address:  key,
+		ASN:      asn,
+		State:    stateName(state),
+		Previous: stateName(p.state),
+	}
+	if p.state == api.PeerState_ESTABLISHED {
+		event.Reason = s.downReasons[key]
+		delete(s.downReasons, key)
+	}
+	p.asn, p.state, p.since = asn, state, event.Time
+	event.EstablishedPeers = s.establishedPeers(nil)
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			zap.S().Warn("Dropping peer event, subscriber too slow", "address", key)
+		}
+	}
+	s.peersMu.Unlock()
+
+	peerASN := strconv.FormatUint(uint64(asn), 10)
+	metrics.BGPPeerState.WithLabelValues(key, peerASN).Set(float64(state))
+	up := 0.0
+	if state == api.PeerState_ESTABLISHED {
+		up = 1
+	}
+	metrics.BGPPeerUp.WithLabelValues(key, peerASN).Set(up)
+	metrics.BGPPeerTransitions.WithLabelValues(key, peerASN, event.State).Inc()
+	metrics.BGPEstablishedPeers.Set(float64(event.EstablishedPeers))
+
+	switch {
+	case state == api.PeerState_ESTABLISHED:
+		zap.S().Info("Peer up", "address", key, "asn", asn, "establishedPeers", event.EstablishedPeers)
+	case event.Previous == stateName(api.PeerState_ESTABLISHED):
+		reason := event.Reason
+		if reason == "" {
+			reason = "unknown"
+		}
+		metrics.BGPPeerDown.WithLabelValues(key, peerASN, reason).Inc()
+		zap.S().Warn("Peer down", "address", key, "asn", asn, "state", event.State, "reason", reason, "establishedPeers", event.EstablishedPeers)
+	default:
+		zap.S().Debug("Peer state changed", "address", key, "from", event.Previous, "to", event.State)
+	}
+}
+
+// forgetPeer stops tracking a deleted neighbor and removes its advertised
+// gauges.
+func (s *Speaker) forgetPeer(address string) {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	delete(s.peers, peerKey(address))
+	delete(s.downReasons, peerKey(address))
+	metrics.BGPEstablishedPeers.Set(float64(s.establishedPeers(nil)))
+	metrics.PrefixAdvertised.DeletePartialMatch(prometheus.Labels{"peer_address": peerKey(address)})
+}
+
+// EstablishedPeers returns the number of established sessions with the
+// neighbors selected by selector, see config.Config.Selects.
+func (s *Speaker) EstablishedPeers(selector []string) int {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	return s.establishedPeers(selector)
+}
+
+// establishedPeers is EstablishedPeers with s.peersMu held.
+func (s *Speaker) establishedPeers(selector []string) int {
+	c := s.Config()
+	n := 0
+	for address, p := range s.peers {
+		if p.state == api.PeerState_ESTABLISHED && c.Selects(selector, address) {
+			n++
+		}
+	}
+	return n
+}
+
+// sessions returns the configured neighbors, then the dynamic neighbors with
+// an established session ordered by address.
+func (s *Speaker) sessions() []config.Neighbor {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	c := s.Config()
+	var dynamic []config.Neighbor
+	for address, p := range s.peers {
+		if p.state != api.PeerState_ESTABLISHED {
+			continue
+		}
+		if n, ok := c.Dynamic(address); ok {
+			dynamic = append(dynamic, n)
+		}
+	}
+	slices.SortFunc(dynamic, func(a, b config.Neighbor) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	return append(slices.Clone(c.Neighbors), dynamic...)
+}
+
+// SubscribePeers returns a channel receiving the current state of every
+// neighbor, then each change, and a function ending the subscription.
+// Events are dropped while the channel is full.
+func (s *Speaker) SubscribePeers() (<-chan PeerEvent, func()) {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	ch := make(chan PeerEvent, max(eventBuffer, len(s.peers)))
+	established := s.establishedPeers(nil)
+	for address, p := range s.peers {
+		ch <- PeerEvent{
+			Time:             p.since,
+			Address:          address,
+			ASN:              p.asn,
+			State:            stateName(p.state),
+			EstablishedPeers: established,
+		}
+	}
+	s.subscribers[ch] = struct{}{}
+	return ch, func() {
+		s.peersMu.Lock()
+		defer s.peersMu.Unlock()
+		delete(s.subscribers, ch)
+	}
+}
+
+// ServeEvents streams the peer events as JSON lines until the client
+// disconnects.
+func (s *Speaker) ServeEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	events, unsubscribe := s.SubscribePeers()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case e := <-events:
+			if err := enc.Encode(e); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.Context.Done():
+			return
+		}
+	}
+}
+
+// stateName returns the lower case name of a session state, e.g.
+// established.
+func stateName(state api.PeerState_SessionState) string {
+	return strings.ToLower(state.String())
+}
@@ -0,0 +1,85 @@
+package speaker
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/osrg/gobgp/v3/api"
+	"github.com/osrg/gobgp/v3/pkg/server"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+	"github.com/ahmet2mir/herald/pkg/logger"
+)
+
+// freePort returns a TCP port of 127.0.0.1 nothing listens on.
+func freePort(t *testing.T) int32 {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return int32(l.Addr().(*net.TCPAddr).Port)
+}
+
+// nextEvent returns the next event of events matching match.
+func nextEvent(t *testing.T, events <-chan PeerEvent, match func(PeerEvent) bool) PeerEvent {
+	t.Helper()
+	timeout := time.After(30 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if match(e) {
+				return e
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for a peer event")
+		}
+	}
+}
+
+// TestPeerDownReason checks the down reason taken from the "Peer Down"
+// message GoBGP logs, whose Key and Reason fields are not part of its API.
+func TestPeerDownReason(t *testing.T) {
+	port := freePort(t)
+	c := testConfig()
+	c.Speaker.ListenPort = port
+	c.Speaker.ListenAddresses = []string{"127.0.0.1"}
+	c.PeerGroups = nil
+	c.Neighbors = []config.Neighbor{{
+		Address:  "127.0.0.1",
+		ASN:      64601,
+		Passive:  true,
+		Families: []string{config.FamilyIPv4Unicast},
+	}}
+	s := newTestSpeaker(t, c)
+	events, unsubscribe := s.SubscribePeers()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	remote := server.NewBgpServer(server.LoggerOption(logger.NewGoBGPLogger()))
+	go remote.Serve()
+	defer remote.Stop()
+	if err := remote.StartBgp(ctx, &api.StartBgpRequest{Global: &api.Global{Asn: 64601, RouterId: "10.0.0.2", ListenPort: -1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.AddPeer(ctx, &api.AddPeerRequest{Peer: &api.Peer{
+		Conf:      &api.PeerConf{NeighborAddress: "127.0.0.1", PeerAsn: 64600},
+		Transport: &api.Transport{RemotePort: uint32(port)},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	nextEvent(t, events, func(e PeerEvent) bool { return e.State == "established" })
+	if err := remote.ShutdownPeer(ctx, &api.ShutdownPeerRequest{Address: "127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	e := nextEvent(t, events, func(e PeerEvent) bool { return e.Previous == "established" })
+	if !strings.HasPrefix(e.Reason, "notification-received") {
+		t.Errorf("reason = %q, want notification-received, has GoBGP changed its Peer Down message?", e.Reason)
+	}
+}
@@ -0,0 +1,198 @@
+package speaker
+
+import (
+	"fmt"
+	"net"
+
+	api "github.com/osrg/gobgp/v3/api"
+	"go.uber.org/zap"
+
+	"github.com/ahmet2mir/herald/pkg/community"
+	"github.com/ahmet2mir/herald/pkg/config"
+)
+
+// exportPolicy is the global export policy implementing the neighbors and
+// neighborOverrides of prefixes. GoBGP only supports per neighbor policies
+// for route server clients, so its statements match both the prefix and the
+// neighbor the route is sent to.
+const exportPolicy = "herald-export"
+
+// setExportPolicy replaces the export policy and its defined sets with the
+// ones built from the prefixes of c. Routes already sent are left as they
+// are, see updateExportPolicy.
+func (s *Speaker) setExportPolicy(c *config.Config) error {
+	var sets []*api.DefinedSet
+	var statements []*api.Statement
+	for _, p := range c.Prefixes {
+		if len(p.Neighbors) == 0 && len(p.NeighborOverrides) == 0 {
+			continue
+		}
+		prefixSet, err := prefixDefinedSet(p)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, prefixSet)
+
+		if len(p.Neighbors) > 0 {
+			neighborSet := neighborDefinedSet(prefixSet.Name+"-neighbors", c.Ranges(p.Neighbors))
+			sets = append(sets, neighborSet)
+			statements = append(statements, &api.Statement{
+				Name: prefixSet.Name + "-reject",
+				Conditions: &api.Conditions{
+					PrefixSet:   &api.MatchSet{Type: api.MatchSet_ANY, Name: prefixSet.Name},
+					NeighborSet: &api.MatchSet{Type: api.MatchSet_INVERT, Name: neighborSet.Name},
+				},
+				Actions: &api.Actions{RouteAction: api.RouteAction_REJECT},
+			})
+		}
+
+		for i, o := range p.NeighborOverrides {
+			neighborSet := neighborDefinedSet(fmt.Sprintf("%s-override-%d", prefixSet.Name, i), c.Ranges(o.Neighbors))
+			sets = append(sets, neighborSet)
+			actions, err := overrideActions(o)
+			if err != nil {
+				return fmt.Errorf("prefix %s: %w", p.IPAddress, err)
+			}
+			statements = append(statements, &api.Statement{
+				Name: neighborSet.Name,
+				Conditions: &api.Conditions{
+					PrefixSet:   &api.MatchSet{Type: api.MatchSet_ANY, Name: prefixSet.Name},
+					NeighborSet: &api.MatchSet{Type: api.MatchSet_ANY, Name: neighborSet.Name},
+				},
+				Actions: actions,
+			})
+		}
+	}
+
+	zap.S().Info("Setting export policy", "statements", len(statements))
+	policy := &api.Policy{Name: exportPolicy, Statements: statements}
+	if err := s.Server.SetPolicies(s.Context, &api.SetPoliciesRequest{
+		DefinedSets: sets,
+		Policies:    []*api.Policy{policy},
+	}); err != nil {
+		return fmt.Errorf("set policies: %w", err)
+	}
+	// SetPolicies keeps the existing assignments.
+	if err := s.Server.SetPolicyAssignment(s.Context, &api.SetPolicyAssignmentRequest{
+		Assignment: &api.PolicyAssignment{
+			Name:          "global",
+			Direction:     api.PolicyDirection_EXPORT,
+			Policies:      []*api.Policy{{Name: exportPolicy}},
+			DefaultAction: api.RouteAction_ACCEPT,
+		},
+	}); err != nil {
+		return fmt.Errorf("set policy assignment: %w", err)
+	}
+	return nil
+}
+
+// updateExportPolicy sets the export policy built from c and sends
+// the routes of changed again. GoBGP neither withdraws the routes a new
+// export policy rejects nor sends withdrawals the current one rejects, so
+// they are withdrawn before the policy changes and announced again after.
+func (s *Speaker) updateExportPolicy(changed []config.Prefix, c *config.Config) error {
+	var paths []*api.Path
+	for _, p := range changed {
+		announced, err := s.localPaths(p)
+		if err != nil {
+			return err
+		}
+		for _, path := range announced {
+			if err := s.Server.DeletePath(s.Context, &api.DeletePathRequest{TableType: api.TableType_GLOBAL, Path: path}); err != nil {
+				return fmt.Errorf("prefix %s: withdraw: %w", p.IPAddress, err)
+			}
+		}
+		paths = append(paths, announced...)
+	}
+
+	if err := s.setExportPolicy(c); err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if _, err := s.Server.AddPath(s.Context, &api.AddPathRequest{TableType: api.TableType_GLOBAL, Path: path}); err != nil {
+			return fmt.Errorf("announce again: %w", err)
+		}
+	}
+	return nil
+}
+
+// localPaths returns the paths of p announced by herald.
+func (s *Speaker) localPaths(p config.Prefix) ([]*api.Path, error) {
+	var paths []*api.Path
+	err := s.Server.ListPath(s.Context, &api.ListPathRequest{
+		TableType: api.TableType_GLOBAL,
+		Family:    apiFamily(p.Family()),
+		Prefixes:  []*api.TableLookupPrefix{{Prefix: p.IPAddress}},
+	}, func(d *api.Destination) {
+		for _, path := range d.Paths {
+			// Locally originated paths have no neighbor address.
+			if net.ParseIP(path.NeighborIp) == nil {
+				paths = append(paths, &api.Path{Family: path.Family, Nlri: path.Nlri, Pattrs: path.Pattrs})
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("prefix %s: list paths: %w", p.IPAddress, err)
+	}
+	return paths, nil
+}
+
+// prefixDefinedSet returns a prefix set matching exactly p.
+func prefixDefinedSet(p config.Prefix) (*api.DefinedSet, error) {
+	_, network, err := net.ParseCIDR(p.IPAddress)
+	if err != nil {
+		return nil, fmt.Errorf("prefix %s: %w", p.IPAddress, err)
+	}
+	length, _ := network.Mask.Size()
+	return &api.DefinedSet{
+		DefinedType: api.DefinedType_PREFIX,
+		Name:        "herald-" + network.String(),
+		Prefixes: []*api.Prefix{{
+			IpPrefix:      network.String(),
+			MaskLengthMin: uint32(length),
+			MaskLengthMax: uint32(length),
+		}},
+	}, nil
+}
+
+// neighborDefinedSet returns a neighbor set matching the neighbors in
+// ranges, see config.Config.Ranges.
+func neighborDefinedSet(name string, ranges []string) *api.DefinedSet {
+	return &api.DefinedSet{DefinedType: api.DefinedType_NEIGHBOR, Name: name, List: ranges}
+}
+
+// overrideActions returns the policy actions applying o, accepting the route
+// so that later overrides are not applied.
+func overrideActions(o config.NeighborOverride) (*api.Actions, error) {
+	actions := &api.Actions{RouteAction: api.RouteAction_ACCEPT}
+	if o.MultiExitDescriminator != 0 {
+		actions.Med = &api.MedAction{Type: api.MedAction_REPLACE, Value: int64(o.MultiExitDescriminator)}
+	}
+	if len(o.AsPathPrepend) > 0 {
+		actions.AsPrepend = &api.AsPrependAction{Asn: o.AsPathPrepend[0], Repeat: uint32(len(o.AsPathPrepend))}
+	}
+	if len(o.Communities) > 0 {
+		communities := make([]string, 0, len(o.Communities))
+		for _, c := range o.Communities {
+			v, err := community.ParseStandard(c)
+			if err != nil {
+				return nil, err
+			}
+			communities = append(communities, fmt.Sprintf("%d:%d", v>>16, v&0xFFFF))
+		}
+		actions.Community = &api.CommunityAction{Type: api.CommunityAction_ADD, Communities: communities}
+	}
+	if len(o.LargeCommunities) > 0 {
+		communities := make([]string, 0, len(o.LargeCommunities))
+		for _, c := range o.LargeCommunities {
+			l, err := community.ParseLarge(c)
+			if err != nil {
+				return nil, err
+			}
+			communities = append(communities, fmt.Sprintf("%d:%d:%d", l.GlobalAdmin, l.LocalData1, l.LocalData2))
+		}
+		actions.LargeCommunity = &api.CommunityAction{Type: api.CommunityAction_ADD, Communities: communities}
+	}
+	return actions, nil
+}
@@ -0,0 +1,144 @@
+package speaker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	api "github.com/osrg/gobgp/v3/api"
+	"go.uber.org/zap"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+	"github.com/ahmet2mir/herald/pkg/metrics"
+)
+
+// RunReconciliation reconciles every interval until the speaker context is
+// cancelled.
+func (s *Speaker) RunReconciliation(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := s.Reconcile(); err != nil {
+				zap.S().Warn("Reconciliation failed", "error", err)
+			}
+		case <-s.Context.Done():
+			return
+		}
+	}
+}
+
+// Reconcile compares the Adj-RIB-Out of each established neighbor, dynamic
+// neighbors included, with the prefixes exported to it and announces the
+// missing ones again. The herald_prefix_advertised gauge reports what was
+// found.
+func (s *Speaker) Reconcile() error {
+	s.mu.Lock()
+	announcements := make([]announcement, 0, len(s.announcements))
+	for _, a := range s.announcements {
+		announcements = append(announcements, *a)
+	}
+	s.mu.Unlock()
+
+	c := s.Config()
+	var errs []error
+	missing := map[string]config.Prefix{}
+	for _, n := range s.sessions() {
+		address := peerKey(n.Address)
+		var exported []announcement
+		for _, a := range announcements {
+			if exportedTo(c, a.prefix, n) {
+				exported = append(exported, a)
+			}
+		}
+		if len(exported) == 0 {
+			continue
+		}
+
+		var advertised map[string]bool
+		if s.EstablishedPeers([]string{address}) == 1 {
+			var err error
+			if advertised, err = s.adjRibOut(address, n.Families); err != nil {
+				errs = append(errs, fmt.Errorf("list adj-rib-out of %s: %w", address, err))
+				continue
+			}
+		}
+		for _, a := range exported {
+			p := a.prefix
+			found := advertised[networkKey(p.IPAddress)]
+			v := 0.0
+			if found {
+				v = 1
+			}
+			metrics.PrefixAdvertised.WithLabelValues(p.IPAddress, p.Name, address).Set(v)
+			switch {
+			case advertised == nil:
+			case a.State != StateWithdrawn && !found:
+				zap.S().Warn("Announced prefix missing from adj-rib-out", "prefix", p.IPAddress, "neighbor", address, "state", a.State)
+				missing[p.IPAddress] = p
+			case a.State == StateWithdrawn && found:
+				zap.S().Warn("Withdrawn prefix still in adj-rib-out", "prefix", p.IPAddress, "neighbor", address)
+			}
+		}
+	}
+
+	for _, p := range missing {
+		if err := s.reinject(p); err != nil {
+			errs = append(errs, fmt.Errorf("announce %s again: %w", p.IPAddress, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// adjRibOut returns the networks in the Adj-RIB-Out of the neighbor at
+// address for families.
+func (s *Speaker) adjRibOut(address string, families []string) (map[string]bool, error) {
+	networks := map[string]bool{}
+	for _, family := range families {
+		err := s.Server.ListPath(s.Context, &api.ListPathRequest{
+			TableType: api.TableType_ADJ_OUT,
+			Name:      address,
+			Family:    apiFamily(family),
+		}, func(d *api.Destination) {
+			networks[networkKey(d.Prefix)] = true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return networks, nil
+}
+
+// reinject sends the current path of p again, unless p was withdrawn since
+// the reconciliation started.
+func (s *Speaker) reinject(p config.Prefix) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.announcements[p.IPAddress]
+	if !ok || a.State == StateWithdrawn || a.path == nil {
+		return nil
+	}
+	if _, err := s.Server.AddPath(s.Context, &api.AddPathRequest{Path: a.path}); err != nil {
+		return err
+	}
+	zap.S().Info("Announced prefix again", "prefix", p.IPAddress, "state", a.State)
+	metrics.PrefixReinjections.WithLabelValues(p.IPAddress, p.Name).Inc()
+	return nil
+}
+
+// exportedTo reports whether p is exported to neighbor n of c.
+func exportedTo(c *config.Config, p config.Prefix, n config.Neighbor) bool {
+	return slices.Contains(n.Families, p.Family()) && c.Selects(p.Neighbors, n.Address)
+}
+
+// networkKey normalizes a prefix to its network, e.g. 192.0.2.0/24 for
+// 192.0.2.1/24.
+func networkKey(prefix string) string {
+	if _, nw, err := net.ParseCIDR(prefix); err == nil {
+		return nw.String()
+	}
+	return prefix
+}
@@ -0,0 +1,148 @@
+package speaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+)
+
+// ReceivedRoute is a route received from a neighbor, before import policies.
+type ReceivedRoute struct {
+	Neighbor        string    `json:"neighbor"`
+	Family          string    `json:"family"`
+	Prefix          string    `json:"prefix"`
+	NextHop         string    `json:"nextHop,omitempty"`
+	ASPath          []uint32  `json:"asPath,omitempty"`
+	Communities     []string  `json:"communities,omitempty"`
+	MED             uint32    `json:"med,omitempty"`
+	LocalPreference uint32    `json:"localPreference,omitempty"`
+	Received        time.Time `json:"received"`
+}
+
+// ReceivedRoutes returns the routes received from the neighbor at address,
+// or from every neighbor and established dynamic neighbor when address is
+// empty, in family, or in every family negotiated with them when family is
+// empty.
+func (s *Speaker) ReceivedRoutes(address, family string) ([]ReceivedRoute, error) {
+	routes := []ReceivedRoute{}
+	for _, n := range s.sessions() {
+		if address != "" && peerKey(address) != peerKey(n.Address) {
+			continue
+		}
+		for _, f := range n.Families {
+			if family != "" && family != f {
+				continue
+			}
+			err := s.Server.ListPath(s.Context, &api.ListPathRequest{
+				TableType: api.TableType_ADJ_IN,
+				Name:      n.Address,
+				Family:    apiFamily(f),
+			}, func(d *api.Destination) {
+				for _, p := range d.Paths {
+					routes = append(routes, receivedRoute(peerKey(n.Address), f, d.Prefix, p))
+				}
+			})
+			if err != nil {
+				return nil, fmt.Errorf("list adj-rib-in of %s: %w", n.Address, err)
+			}
+		}
+	}
+	return routes, nil
+}
+
+// receivedRoute decodes the attributes of path.
+func receivedRoute(neighbor, family, prefix string, path *api.Path) ReceivedRoute {
+	r := ReceivedRoute{Neighbor: neighbor, Family: family, Prefix: prefix}
+	if path.Age != nil {
+		r.Received = path.Age.AsTime()
+	}
+	for _, attr := range path.Pattrs {
+		m, err := attr.UnmarshalNew()
+		if err != nil {
+			continue
+		}
+		r.decode(m)
+	}
+	return r
+}
+
+// decode sets the field of r held by attribute m.
+func (r *ReceivedRoute) decode(m proto.Message) {
+	switch a := m.(type) {
+	case *api.NextHopAttribute:
+		r.NextHop = a.NextHop
+	case *api.MpReachNLRIAttribute:
+		if len(a.NextHops) > 0 {
+			r.NextHop = a.NextHops[0]
+		}
+	case *api.AsPathAttribute:
+		for _, segment := range a.Segments {
+			r.ASPath = append(r.ASPath, segment.Numbers...)
+		}
+	case *api.CommunitiesAttribute:
+		for _, c := range a.Communities {
+			r.Communities = append(r.Communities, fmt.Sprintf("%d:%d", c>>16, c&0xffff))
+		}
+	case *api.MultiExitDiscAttribute:
+		r.MED = a.Med
+	case *api.LocalPrefAttribute:
+		r.LocalPreference = a.LocalPref
+	}
+}
+
+// Receiving reports whether a route to one of prefixes is received from one
+// of the neighbors selected by selector, see config.Config.Selects.
+func (s *Speaker) Receiving(prefixes, selector []string) (bool, error) {
+	c := s.Config()
+	for _, n := range s.sessions() {
+		if !c.Selects(selector, n.Address) {
+			continue
+		}
+		for _, prefix := range prefixes {
+			family := (&config.Prefix{IPAddress: prefix}).Family()
+			if !slices.Contains(n.Families, family) {
+				continue
+			}
+			found := false
+			err := s.Server.ListPath(s.Context, &api.ListPathRequest{
+				TableType: api.TableType_ADJ_IN,
+				Name:      n.Address,
+				Family:    apiFamily(family),
+				Prefixes:  []*api.TableLookupPrefix{{Prefix: prefix, Type: api.TableLookupPrefix_EXACT}},
+			}, func(d *api.Destination) {
+				found = found || len(d.Paths) > 0
+			})
+			if err != nil {
+				return false, fmt.Errorf("list adj-rib-in of %s: %w", n.Address, err)
+			}
+			if found {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// ServeRoutes writes the received routes as JSON, filtered by the neighbor
+// and family query parameters.
+func (s *Speaker) ServeRoutes(w http.ResponseWriter, r *http.Request) {
+	family := r.URL.Query().Get("family")
+	if family != "" && family != config.FamilyIPv4Unicast && family != config.FamilyIPv6Unicast {
+		http.Error(w, fmt.Sprintf("family must be %s or %s", config.FamilyIPv4Unicast, config.FamilyIPv6Unicast), http.StatusBadRequest)
+		return
+	}
+	routes, err := s.ReceivedRoutes(r.URL.Query().Get("neighbor"), family)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(routes)
+}
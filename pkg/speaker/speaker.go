@@ -3,32 +3,63 @@ package speaker
 import (
 	"context"
 	"fmt"
+	"math"
 	"net"
-	"regexp"
-	"strconv"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	api "github.com/osrg/gobgp/v3/api"
 	"github.com/osrg/gobgp/v3/pkg/packet/bgp"
 	"github.com/osrg/gobgp/v3/pkg/server"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 
+	"github.com/ahmet2mir/herald/pkg/address"
+	"github.com/ahmet2mir/herald/pkg/community"
 	"github.com/ahmet2mir/herald/pkg/config"
 	"github.com/ahmet2mir/herald/pkg/logger"
 )
 
 type Speaker struct {
-	Config  *config.Config
 	Server  *server.BgpServer
 	Context context.Context
+
+	// config is the running configuration, replaced on reload while
+	// announcers and the reconciliation read it.
+	config atomic.Pointer[config.Config]
+
+	mu            sync.Mutex
+	announcements map[string]*announcement
+
+	peersMu     sync.Mutex
+	peers       map[string]*peer
+	downReasons map[string]string
+	subscribers map[chan PeerEvent]struct{}
 }
 
 func New(c *config.Config, ctx context.Context) (*Speaker, error) {
-	s := server.NewBgpServer(
+	sp := &Speaker{
+		Context:       ctx,
+		announcements: make(map[string]*announcement),
+		peers:         make(map[string]*peer),
+		downReasons:   make(map[string]string),
+		subscribers:   make(map[chan PeerEvent]struct{}),
+	}
+	sp.Server = server.NewBgpServer(
 		server.GrpcListenAddress(c.API.GetURI()),
-		server.LoggerOption(logger.NewGoBGPLogger()),
+		server.LoggerOption(&peerLogger{Logger: logger.NewGoBGPLogger(), speaker: sp}),
 	)
-	return &Speaker{Config: c, Server: s, Context: ctx}, nil
+	sp.config.Store(c)
+	return sp, nil
+}
+
+// Config returns the running configuration.
+func (s *Speaker) Config() *config.Config {
+	return s.config.Load()
 }
 
 func (s *Speaker) Stop() {
@@ -36,6 +67,7 @@ func (s *Speaker) Stop() {
 		zap.S().Warn("Unable to top bgp %w", err)
 	}
 	s.Server.Stop()
+	s.removeAddresses()
 }
 
 func (s *Speaker) Serve() {
@@ -46,22 +78,103 @@ func (s *Speaker) Start() error {
 	if err := s.startBgp(); err != nil {
 		return fmt.Errorf("setup error starting bgp: %w", err)
 	}
+	if err := s.setExportPolicy(s.Config()); err != nil {
+		return fmt.Errorf("setup error setting export policy: %w", err)
+	}
+	if err := s.watchPeers(); err != nil {
+		return fmt.Errorf("setup error watching peers: %w", err)
+	}
+	if err := s.addBmp(); err != nil {
+		return fmt.Errorf("setup error adding bmp collector: %w", err)
+	}
+	if err := s.enableMrt(); err != nil {
+		return fmt.Errorf("setup error enabling mrt dumps: %w", err)
+	}
 	if err := s.addNeighbors(); err != nil {
 		return fmt.Errorf("setup error adding neighbors: %w", err)
 	}
 	return nil
 }
 
+// enableMrt starts the MRT dumps. Table dumps are taken at each rotation.
+func (s *Speaker) enableMrt() error {
+	m := s.Config().MRT
+	if m == nil || !m.Enabled {
+		return nil
+	}
+	dumps := []struct {
+		dumpType api.EnableMrtRequest_DumpType
+		dump     *config.MRTDump
+	}{
+		{api.EnableMrtRequest_UPDATES, m.Updates},
+		{api.EnableMrtRequest_TABLE, m.Table},
+	}
+	for _, d := range dumps {
+		if d.dump == nil {
+			continue
+		}
+		zap.S().Info("Enabling MRT dump", "type", d.dumpType, "fileName", d.dump.FileName, "rotationInterval", d.dump.RotationInterval)
+		if err := s.Server.EnableMrt(s.Context, &api.EnableMrtRequest{
+			Type:             d.dumpType,
+			Filename:         d.dump.FileName,
+			RotationInterval: uint64(d.dump.RotationInterval / time.Second),
+		}); err != nil {
+			return fmt.Errorf("%s: %w", d.dump.FileName, err)
+		}
+	}
+	return nil
+}
+
+// bmpPolicies maps the route monitoring policies of the bmp section to
+// GoBGP.
+var bmpPolicies = map[string]api.AddBmpRequest_MonitoringPolicy{
+	config.BMPPrePolicy:  api.AddBmpRequest_PRE,
+	config.BMPPostPolicy: api.AddBmpRequest_POST,
+	config.BMPBoth:       api.AddBmpRequest_BOTH,
+	config.BMPLocalRIB:   api.AddBmpRequest_LOCAL,
+	config.BMPAll:        api.AddBmpRequest_ALL,
+}
+
+// addBmp connects to the BMP collector, before the neighbors are added so
+// that it sees their sessions come up.
+func (s *Speaker) addBmp() error {
+	b := s.Config().BMP
+	if b == nil || !b.Enabled {
+		return nil
+	}
+	sysName := b.SysName
+	if sysName == "" {
+		sysName, _ = os.Hostname()
+	}
+	zap.S().Info("Adding BMP collector", "address", b.Address, "port", b.Port, "policy", b.RouteMonitoringPolicy)
+	return s.Server.AddBmp(s.Context, &api.AddBmpRequest{
+		Address:           b.Address,
+		Port:              uint32(b.Port),
+		Policy:            bmpPolicies[b.RouteMonitoringPolicy],
+		StatisticsTimeout: int32(b.StatisticsInterval / time.Second),
+		SysName:           sysName,
+		SysDescr:          "herald",
+	})
+}
+
 func (s *Speaker) startBgp() error {
+	// GoBGP listens on port 179 when ListenPort is 0, herald only when
+	// configured to.
+	sp := s.Config().Speaker
+	listenPort := sp.ListenPort
+	if listenPort == 0 {
+		listenPort = -1
+	}
 	g := &api.Global{
-		Asn:        s.Config.Speaker.ASN,
-		RouterId:   s.Config.Speaker.RouterID,
-		ListenPort: -1,
+		Asn:             sp.ASN,
+		RouterId:        sp.RouterID,
+		ListenPort:      listenPort,
+		ListenAddresses: sp.ListenAddresses,
 	}
-	if s.Config.Speaker.GracefulRestartEnabled {
+	if sp.GracefulRestartEnabled {
 		g.GracefulRestart = &api.GracefulRestart{
 			Enabled:     true,
-			RestartTime: s.Config.Speaker.GracefulRestartRestartTime,
+			RestartTime: sp.GracefulRestartRestartTime,
 		}
 	} else {
 		g.GracefulRestart = &api.GracefulRestart{
@@ -72,26 +185,253 @@ func (s *Speaker) startBgp() error {
 }
 
 func (s *Speaker) addNeighbors() error {
-	for _, neighbor := range s.Config.Neighbors {
-		peer := &api.Peer{
-			Conf: &api.PeerConf{
-				NeighborAddress: neighbor.Address,
-				PeerAsn:         neighbor.ASN,
-			},
-			EbgpMultihop: &api.EbgpMultihop{
-				Enabled: neighbor.EbgpMultihopEnabled,
-			},
+	for _, neighbor := range s.Config().Neighbors {
+		zap.S().Info("NeighborAddress", neighbor.Address, "PeerAsn", neighbor.ASN, "Enabled", neighbor.EbgpMultihopEnabled, "Passive", neighbor.Passive)
+
+		if err := s.Server.AddPeer(s.Context, &api.AddPeerRequest{Peer: neighborPeer(neighbor)}); err != nil {
+			return err
 		}
+	}
+	return s.addDynamicNeighbors()
+}
 
-		zap.S().Info("NeighborAddress", neighbor.Address, "PeerAsn", neighbor.ASN, "Enabled", neighbor.EbgpMultihopEnabled)
+// addDynamicNeighbors adds the peer groups of the dynamic neighbors, then
+// the ranges accepting sessions with their settings.
+func (s *Speaker) addDynamicNeighbors() error {
+	c := s.Config()
+	added := map[string]bool{}
+	for _, d := range c.DynamicNeighbors {
+		if !added[d.PeerGroup] {
+			zap.S().Info("Adding peer group", "name", d.PeerGroup)
+			if err := s.Server.AddPeerGroup(s.Context, &api.AddPeerGroupRequest{
+				PeerGroup: peerGroup(d.PeerGroup, c.PeerGroups[d.PeerGroup]),
+			}); err != nil {
+				return fmt.Errorf("peer group %s: %w", d.PeerGroup, err)
+			}
+			added[d.PeerGroup] = true
+		}
+		zap.S().Info("Adding dynamic neighbor", "prefix", d.Prefix, "peerGroup", d.PeerGroup)
+		if err := s.Server.AddDynamicNeighbor(s.Context, &api.AddDynamicNeighborRequest{
+			DynamicNeighbor: &api.DynamicNeighbor{Prefix: d.Prefix, PeerGroup: d.PeerGroup},
+		}); err != nil {
+			return fmt.Errorf("dynamic neighbor %s: %w", d.Prefix, err)
+		}
+	}
+	return nil
+}
 
-		if err := s.Server.AddPeer(s.Context, &api.AddPeerRequest{Peer: peer}); err != nil {
-			return err
+// peerGroup returns the GoBGP peer group named name with the settings of
+// neighbor. Sessions from dynamic neighbors are always passive.
+func peerGroup(name string, neighbor config.Neighbor) *api.PeerGroup {
+	p := neighborPeer(neighbor)
+	p.Transport.PassiveMode = true
+	return &api.PeerGroup{
+		Conf: &api.PeerGroupConf{
+			PeerGroupName: name,
+			PeerAsn:       p.Conf.PeerAsn,
+			AuthPassword:  p.Conf.AuthPassword,
+		},
+		EbgpMultihop: p.EbgpMultihop,
+		TtlSecurity:  p.TtlSecurity,
+		Timers:       p.Timers,
+		Transport:    p.Transport,
+		AfiSafis:     p.AfiSafis,
+	}
+}
+
+func neighborPeer(neighbor config.Neighbor) *api.Peer {
+	afiSafis := make([]*api.AfiSafi, 0, len(neighbor.Families))
+	for _, family := range neighbor.Families {
+		afiSafis = append(afiSafis, &api.AfiSafi{
+			Config: &api.AfiSafiConfig{Family: apiFamily(family), Enabled: true},
+		})
+	}
+	return &api.Peer{
+		Conf: &api.PeerConf{
+			NeighborAddress: neighbor.Address,
+			PeerAsn:         neighbor.ASN,
+			AuthPassword:    neighbor.Password.Reveal(),
+		},
+		EbgpMultihop: &api.EbgpMultihop{
+			Enabled:     neighbor.EbgpMultihopEnabled,
+			MultihopTtl: uint32(neighbor.EbgpMultihopTTL),
+		},
+		TtlSecurity: &api.TtlSecurity{
+			Enabled: neighbor.TTLSecurityEnabled,
+			TtlMin:  uint32(neighbor.TTLMin),
+		},
+		Timers: &api.Timers{
+			Config: &api.TimersConfig{
+				HoldTime:          uint64(neighbor.HoldTime / time.Second),
+				KeepaliveInterval: uint64(neighbor.KeepaliveInterval / time.Second),
+				ConnectRetry:      uint64(neighbor.ConnectRetry / time.Second),
+			},
+		},
+		Transport: &api.Transport{
+			LocalAddress: neighbor.LocalAddress,
+			PassiveMode:  neighbor.Passive,
+		},
+		AfiSafis: afiSafis,
+	}
+}
+
+// apiFamily returns the GoBGP family of a configuration family.
+func apiFamily(family string) *api.Family {
+	if family == config.FamilyIPv6Unicast {
+		return &api.Family{Afi: api.Family_AFI_IP6, Safi: api.Family_SAFI_UNICAST}
+	}
+	return &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST}
+}
+
+// Reload applies the neighbor changes between the running configuration and
+// c: new neighbors are added, removed ones are deleted and changed ones are
+// updated in place, leaving untouched sessions alone. Speaker and API
+// settings cannot change without a restart and are reported as warnings.
+// When a change fails, the changes already made are undone and the running
+// configuration is kept.
+func (s *Speaker) Reload(c *config.Config) error {
+	old := s.Config()
+	if !reflect.DeepEqual(old.Speaker, c.Speaker) {
+		zap.S().Warn("Reload: speaker settings changed, restart herald to apply them")
+	}
+	if !reflect.DeepEqual(old.API, c.API) {
+		zap.S().Warn("Reload: api settings changed, restart herald to apply them")
+	}
+
+	undo, err := s.reloadNeighbors(old, c)
+	if err == nil {
+		// Added and removed prefixes with neighbors or neighborOverrides
+		// change the policy without any route to send again.
+		if !reflect.DeepEqual(exportSettings(old), exportSettings(c)) {
+			changed := exportChanged(old, c)
+			zap.S().Info("Reload: updating export policy", "prefixes", len(changed))
+			if err = s.updateExportPolicy(changed, c); err != nil {
+				if restoreErr := s.updateExportPolicy(changed, old); restoreErr != nil {
+					zap.S().Error("Reload: failed to restore export policy", restoreErr)
+				}
+			}
 		}
 	}
+	if err != nil {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](); undoErr != nil {
+				zap.S().Error("Reload: failed to undo neighbor change", undoErr)
+			}
+		}
+		return err
+	}
+
+	s.config.Store(c)
 	return nil
 }
 
+// reloadNeighbors applies the neighbor changes between old and c until one
+// fails, returning the functions undoing those made.
+func (s *Speaker) reloadNeighbors(old, c *config.Config) ([]func() error, error) {
+	running := make(map[string]config.Neighbor, len(old.Neighbors))
+	for _, n := range old.Neighbors {
+		running[n.Address] = n
+	}
+	wanted := make(map[string]config.Neighbor, len(c.Neighbors))
+	for _, n := range c.Neighbors {
+		wanted[n.Address] = n
+	}
+
+	var undo []func() error
+	for address, n := range running {
+		if _, ok := wanted[address]; ok {
+			continue
+		}
+		zap.S().Info("Reload: deleting neighbor", "address", address)
+		if err := s.Server.DeletePeer(s.Context, &api.DeletePeerRequest{Address: address}); err != nil {
+			return undo, fmt.Errorf("delete neighbor %s: %w", address, err)
+		}
+		s.forgetPeer(address)
+		undo = append(undo, func() error {
+			return s.Server.AddPeer(s.Context, &api.AddPeerRequest{Peer: neighborPeer(n)})
+		})
+	}
+	for _, n := range c.Neighbors {
+		previous, ok := running[n.Address]
+		switch {
+		case !ok:
+			zap.S().Info("Reload: adding neighbor", "address", n.Address)
+			if err := s.Server.AddPeer(s.Context, &api.AddPeerRequest{Peer: neighborPeer(n)}); err != nil {
+				return undo, fmt.Errorf("add neighbor %s: %w", n.Address, err)
+			}
+			undo = append(undo, func() error {
+				defer s.forgetPeer(n.Address)
+				return s.Server.DeletePeer(s.Context, &api.DeletePeerRequest{Address: n.Address})
+			})
+		case !reflect.DeepEqual(previous, n):
+			zap.S().Info("Reload: updating neighbor", "address", n.Address)
+			resp, err := s.Server.UpdatePeer(s.Context, &api.UpdatePeerRequest{Peer: neighborPeer(n)})
+			if err != nil {
+				return undo, fmt.Errorf("update neighbor %s: %w", n.Address, err)
+			}
+			undo = append(undo, func() error {
+				_, err := s.Server.UpdatePeer(s.Context, &api.UpdatePeerRequest{Peer: neighborPeer(previous)})
+				return err
+			})
+			if resp.NeedsSoftResetIn {
+				if err := s.Server.ResetPeer(s.Context, &api.ResetPeerRequest{
+					Address:   n.Address,
+					Soft:      true,
+					Direction: api.ResetPeerRequest_IN,
+				}); err != nil {
+					return undo, fmt.Errorf("soft reset neighbor %s: %w", n.Address, err)
+				}
+			}
+		}
+	}
+	return undo, nil
+}
+
+// exportSettings returns the neighbors and neighborOverrides of the
+// prefixes of c that have some, by IP address, with peer groups replaced by
+// their members. The export policy built from c only depends on them.
+func exportSettings(c *config.Config) map[string]config.Prefix {
+	settings := make(map[string]config.Prefix)
+	for _, p := range c.Prefixes {
+		if len(p.Neighbors) == 0 && len(p.NeighborOverrides) == 0 {
+			continue
+		}
+		overrides := make([]config.NeighborOverride, 0, len(p.NeighborOverrides))
+		for _, o := range p.NeighborOverrides {
+			o.Neighbors = c.Ranges(o.Neighbors)
+			overrides = append(overrides, o)
+		}
+		settings[p.IPAddress] = config.Prefix{Neighbors: c.Ranges(p.Neighbors), NeighborOverrides: overrides}
+	}
+	return settings
+}
+
+// exportChanged returns the prefixes of old, kept in new, whose neighbors or
+// neighborOverrides differ in new, including through the members of the
+// peer groups they select. Their routes must be sent again when the export
+// policy changes.
+func exportChanged(old, new *config.Config) []config.Prefix {
+	before, after := exportSettings(old), exportSettings(new)
+	kept := make(map[string]bool, len(new.Prefixes))
+	for _, p := range new.Prefixes {
+		kept[p.IPAddress] = true
+	}
+	var changed []config.Prefix
+	for _, p := range old.Prefixes {
+		if kept[p.IPAddress] && !reflect.DeepEqual(before[p.IPAddress], after[p.IPAddress]) {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// origins maps configuration origins to ORIGIN attribute values.
+var origins = map[string]uint32{
+	config.OriginIGP:        uint32(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+	config.OriginEGP:        uint32(bgp.BGP_ORIGIN_ATTR_TYPE_EGP),
+	config.OriginIncomplete: uint32(bgp.BGP_ORIGIN_ATTR_TYPE_INCOMPLETE),
+}
+
 func (s *Speaker) anycastPath(p config.Prefix) (*api.Path, error) {
 	ip, nw, err := net.ParseCIDR(p.IPAddress)
 	if err != nil {
@@ -105,78 +445,221 @@ func (s *Speaker) anycastPath(p config.Prefix) (*api.Path, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error creating network layer reachability information: %w", err)
 	}
-	attrs := make([]*anypb.Any, 0, 1)
 
-	origin := &api.OriginAttribute{
-		Origin: uint32(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+	globalNextHop, err := nextHop(p)
+	if err != nil {
+		return nil, err
 	}
-	if attr, err := anypb.New(origin); err != nil {
-		return nil, fmt.Errorf("error origin %w", err)
-	} else {
-		attrs = append(attrs, attr)
+	// IPv4 routes carry their next hop in NEXT_HOP, IPv6 ones in
+	// MP_REACH_NLRI with an optional link-local next hop (RFC 2545).
+	family := apiFamily(p.Family())
+	var nextHop proto.Message = &api.NextHopAttribute{
+		NextHop: globalNextHop,
+	}
+	if family.Afi == api.Family_AFI_IP6 {
+		nextHops := []string{globalNextHop}
+		if p.NextHopLinkLocal != "" {
+			nextHops = append(nextHops, p.NextHopLinkLocal)
+		}
+		nextHop = &api.MpReachNLRIAttribute{
+			Family:   family,
+			NextHops: nextHops,
+			Nlris:    []*anypb.Any{nlri},
+		}
 	}
 
-	nextHop := &api.NextHopAttribute{
-		NextHop: p.NextHop,
+	origin, ok := origins[p.Origin]
+	if !ok {
+		origin = uint32(bgp.BGP_ORIGIN_ATTR_TYPE_IGP)
 	}
-	if attr, err := anypb.New(nextHop); err != nil {
-		return nil, fmt.Errorf("error nextHop %w", err)
-	} else {
-		attrs = append(attrs, attr)
+	messages := []proto.Message{
+		&api.OriginAttribute{Origin: origin},
+		nextHop,
 	}
 
-	var ucom []uint32
-	var _regexpCommunity = regexp.MustCompile(`(\d+):(\d+)`)
-	zap.S().Info("p.Communities", "p.Communities", p.Communities)
-
-	for _, c := range p.Communities {
-		i, err := strconv.ParseUint(c, 10, 32)
-		if err == nil {
-			ucom = append(ucom, uint32(i))
-		} else {
-			elems := _regexpCommunity.FindStringSubmatch(c)
-			if len(elems) == 3 {
-				fst, _ := strconv.ParseUint(elems[1], 10, 16)
-				snd, _ := strconv.ParseUint(elems[2], 10, 16)
-				ucom = append(ucom, uint32(fst<<16|snd))
-			}
-		}
+	// GoBGP prepends the speaker AS number for eBGP neighbors.
+	asn := s.Config().Speaker.ASN
+	if asPath := p.ASPath(asn); len(asPath) > 0 {
+		messages = append(messages, &api.AsPathAttribute{
+			Segments: []*api.AsSegment{{Type: api.AsSegment_AS_SEQUENCE, Numbers: asPath}},
+		})
 	}
-	communities := &api.CommunitiesAttribute{
-		Communities: ucom,
+	if p.MultiExitDescriminator != 0 {
+		messages = append(messages, &api.MultiExitDiscAttribute{Med: p.MultiExitDescriminator})
 	}
-	if attr, err := anypb.New(communities); err != nil {
-		return nil, fmt.Errorf("error communities %w", err)
-	} else {
+	if p.LocalPreference != 0 {
+		messages = append(messages, &api.LocalPrefAttribute{LocalPref: p.LocalPreference})
+	}
+
+	communities, err := communityAttributes(p, asn)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, communities...)
+
+	attrs := make([]*anypb.Any, 0, len(messages))
+	for _, m := range messages {
+		attr, err := anypb.New(m)
+		if err != nil {
+			return nil, fmt.Errorf("error %T %w", m, err)
+		}
 		attrs = append(attrs, attr)
 	}
 
-	zap.S().Info("Attributes", "communities", communities)
-	zap.S().Info("Attributes", "nextHop", nextHop)
-	zap.S().Info("Attributes", "origin", origin)
+	zap.S().Debug("Attributes", "prefix", p.IPAddress, "attributes", messages)
 
 	return &api.Path{
-		Family: &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST},
+		Family: family,
 		Nlri:   nlri,
 		Pattrs: attrs,
 	}, nil
 }
 
+// nextHop returns the next hop of p: nextHop, the primary address of
+// nextHopInterface, or the unspecified address of its family, which GoBGP
+// replaces with the local address of each session.
+func nextHop(p config.Prefix) (string, error) {
+	ipv6 := p.Family() == config.FamilyIPv6Unicast
+	switch {
+	case p.NextHop != "":
+		return p.NextHop, nil
+	case p.NextHopInterface != "":
+		ip, err := address.Primary(p.NextHopInterface, ipv6)
+		if err != nil {
+			return "", fmt.Errorf("next hop of %s: %w", p.IPAddress, err)
+		}
+		return ip.String(), nil
+	case ipv6:
+		return net.IPv6unspecified.String(), nil
+	default:
+		return net.IPv4zero.String(), nil
+	}
+}
+
+// communityAttributes returns the COMMUNITIES, EXTENDED_COMMUNITIES and
+// LARGE_COMMUNITY attributes of p, leaving out empty ones. asn is the
+// speaker AS number.
+func communityAttributes(p config.Prefix, asn uint32) ([]proto.Message, error) {
+	var messages []proto.Message
+
+	if len(p.Communities) > 0 {
+		standard := make([]uint32, 0, len(p.Communities))
+		for _, c := range p.Communities {
+			v, err := community.ParseStandard(c)
+			if err != nil {
+				return nil, err
+			}
+			standard = append(standard, v)
+		}
+		messages = append(messages, &api.CommunitiesAttribute{Communities: standard})
+	}
+
+	var extended []*anypb.Any
+	if lb := p.LinkBandwidth; lb != nil && lb.Bandwidth != "" {
+		a, err := linkBandwidth(lb, asn)
+		if err != nil {
+			return nil, err
+		}
+		extended = append(extended, a)
+	}
+	if len(p.ExtendedCommunities) > 0 {
+		for _, c := range p.ExtendedCommunities {
+			e, err := community.ParseExtended(c)
+			if err != nil {
+				return nil, err
+			}
+			a, err := anypb.New(extendedCommunity(e))
+			if err != nil {
+				return nil, fmt.Errorf("error extended community %q %w", c, err)
+			}
+			extended = append(extended, a)
+		}
+	}
+	if len(extended) > 0 {
+		messages = append(messages, &api.ExtendedCommunitiesAttribute{Communities: extended})
+	}
+
+	if len(p.LargeCommunities) > 0 {
+		large := make([]*api.LargeCommunity, 0, len(p.LargeCommunities))
+		for _, c := range p.LargeCommunities {
+			l, err := community.ParseLarge(c)
+			if err != nil {
+				return nil, err
+			}
+			large = append(large, &api.LargeCommunity{
+				GlobalAdmin: l.GlobalAdmin,
+				LocalData1:  l.LocalData1,
+				LocalData2:  l.LocalData2,
+			})
+		}
+		messages = append(messages, &api.LargeCommunitiesAttribute{Communities: large})
+	}
+
+	return messages, nil
+}
+
+// linkBandwidth returns the link bandwidth extended community of lb, whose
+// value is in bytes per second. asn is the speaker AS number.
+func linkBandwidth(lb *config.LinkBandwidth, asn uint32) (*anypb.Any, error) {
+	bps, err := config.ParseBandwidth(lb.Bandwidth)
+	if err != nil {
+		return nil, fmt.Errorf("link bandwidth: %w", err)
+	}
+	if lb.ASN != 0 {
+		asn = lb.ASN
+	}
+	if asn > math.MaxUint16 {
+		asn = bgp.AS_TRANS
+	}
+	a, err := anypb.New(&api.LinkBandwidthExtended{Asn: asn, Bandwidth: float32(bps / 8)})
+	if err != nil {
+		return nil, fmt.Errorf("error link bandwidth %w", err)
+	}
+	return a, nil
+}
+
+// extendedCommunity converts e to its GoBGP API message.
+func extendedCommunity(e *community.Extended) proto.Message {
+	subType := uint32(bgp.EC_SUBTYPE_ROUTE_TARGET)
+	if e.Type == community.RouteOrigin {
+		subType = uint32(bgp.EC_SUBTYPE_ROUTE_ORIGIN)
+	}
+	switch {
+	case e.IP != nil:
+		return &api.IPv4AddressSpecificExtended{IsTransitive: true, SubType: subType, Address: e.IP.String(), LocalAdmin: e.LocalAdmin}
+	case e.FourOctet:
+		return &api.FourOctetAsSpecificExtended{IsTransitive: true, SubType: subType, Asn: e.ASN, LocalAdmin: e.LocalAdmin}
+	default:
+		return &api.TwoOctetAsSpecificExtended{IsTransitive: true, SubType: subType, Asn: e.ASN, LocalAdmin: e.LocalAdmin}
+	}
+}
+
+// AddPath announces p, sending an update only when it was not announced
+// with the same attributes.
 func (s *Speaker) AddPath(p config.Prefix) error {
 	path, err := s.anycastPath(p)
 	if err != nil {
 		return err
 	}
-	zap.S().Info("addPath", "anycast_ip", p.IPAddress)
-	_, err = s.Server.AddPath(s.Context, &api.AddPathRequest{Path: path})
-	return err
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.announce(p, StateAnnounced, path)
 }
 
-func (s *Speaker) DeletePath(p config.Prefix) error {
-	bgpPath, err := s.anycastPath(p)
+// DegradePath announces p with its degraded attributes.
+func (s *Speaker) DegradePath(p config.Prefix) error {
+	path, err := s.anycastPath(p.DegradedPrefix())
 	if err != nil {
 		return err
 	}
-	zap.S().Warn("deletePath", "anycast_ip", p.IPAddress)
-	return s.Server.DeletePath(s.Context, &api.DeletePathRequest{Path: bgpPath})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.announce(p, StateDegraded, path)
+}
+
+// DeletePath withdraws p unless it is already withdrawn.
+func (s *Speaker) DeletePath(p config.Prefix) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.withdraw(p)
 }
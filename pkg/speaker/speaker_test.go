@@ -0,0 +1,256 @@
+package speaker
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	api "github.com/osrg/gobgp/v3/api"
+
+	"github.com/ahmet2mir/herald/pkg/config"
+)
+
+// newTestSpeaker starts a speaker running c, which should have no
+// neighbors to stay offline.
+func newTestSpeaker(t *testing.T, c *config.Config) *Speaker {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := New(c, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve()
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.Stop()
+		cancel()
+	})
+	return s
+}
+
+func testConfig(prefixes ...config.Prefix) *config.Config {
+	return &config.Config{
+		API:      config.ConfigAPI{ListenAddress: "127.0.0.1", ListenPort: 0},
+		Speaker:  config.Speaker{ASN: 64600, RouterID: "10.0.0.1"},
+		Prefixes: prefixes,
+		PeerGroups: map[string]config.Neighbor{
+			"spines": {},
+		},
+		Neighbors: []config.Neighbor{
+			{Address: "192.0.2.10", PeerGroup: "spines"},
+			{Address: "192.0.2.11", PeerGroup: "spines"},
+		},
+	}
+}
+
+// exportStatements returns the names of the export policy statements.
+func exportStatements(t *testing.T, s *Speaker) []string {
+	t.Helper()
+	var names []string
+	err := s.Server.ListPolicy(s.Context, &api.ListPolicyRequest{Name: exportPolicy}, func(p *api.Policy) {
+		for _, st := range p.Statements {
+			names = append(names, st.Name)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestExportChanged(t *testing.T) {
+	plain := config.Prefix{IPAddress: "198.51.100.1/32"}
+	selected := config.Prefix{IPAddress: "198.51.100.1/32", Neighbors: []string{"192.0.2.10"}}
+	group := config.Prefix{IPAddress: "198.51.100.1/32", Neighbors: []string{"spines"}}
+	overridden := config.Prefix{IPAddress: "198.51.100.1/32", NeighborOverrides: []config.NeighborOverride{
+		{Neighbors: []string{"192.0.2.10"}, MultiExitDescriminator: 10},
+	}}
+	other := config.Prefix{IPAddress: "198.51.100.2/32", Neighbors: []string{"192.0.2.10"}}
+
+	tests := []struct {
+		name        string
+		old, new    *config.Config
+		want        []string
+		wantRebuild bool
+	}{
+		{
+			name: "unchanged",
+			old:  testConfig(selected, other),
+			new:  testConfig(selected, other),
+		},
+		{
+			name: "other attributes changed",
+			old:  testConfig(plain),
+			new:  testConfig(config.Prefix{IPAddress: plain.IPAddress, MultiExitDescriminator: 10}),
+		},
+		{
+			name:        "neighbors added",
+			old:         testConfig(plain),
+			new:         testConfig(selected),
+			want:        []string{selected.IPAddress},
+			wantRebuild: true,
+		},
+		{
+			name:        "neighbors removed",
+			old:         testConfig(selected),
+			new:         testConfig(plain),
+			want:        []string{selected.IPAddress},
+			wantRebuild: true,
+		},
+		{
+			name:        "neighbor overrides changed",
+			old:         testConfig(selected),
+			new:         testConfig(overridden),
+			want:        []string{selected.IPAddress},
+			wantRebuild: true,
+		},
+		{
+			name: "peer group members changed",
+			old:  testConfig(group),
+			new: func() *config.Config {
+				c := testConfig(group)
+				c.Neighbors = c.Neighbors[:1]
+				return c
+			}(),
+			want:        []string{group.IPAddress},
+			wantRebuild: true,
+		},
+		{
+			name:        "prefix with neighbors added",
+			old:         testConfig(plain),
+			new:         testConfig(plain, other),
+			wantRebuild: true,
+		},
+		{
+			name:        "prefix with neighbors removed",
+			old:         testConfig(plain, other),
+			new:         testConfig(plain),
+			wantRebuild: true,
+		},
+		{
+			name: "prefix without neighbors added",
+			old:  testConfig(other),
+			new:  testConfig(other, plain),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range exportChanged(tt.old, tt.new) {
+				got = append(got, p.IPAddress)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("exportChanged = %v, want %v", got, tt.want)
+			}
+			rebuild := !reflect.DeepEqual(exportSettings(tt.old), exportSettings(tt.new))
+			if rebuild != tt.wantRebuild {
+				t.Errorf("export settings changed = %t, want %t", rebuild, tt.wantRebuild)
+			}
+		})
+	}
+}
+
+func TestSetExportPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefixes []config.Prefix
+		want     []string
+	}{
+		{
+			name:     "every neighbor",
+			prefixes: []config.Prefix{{IPAddress: "198.51.100.1/32"}},
+		},
+		{
+			name:     "neighbors",
+			prefixes: []config.Prefix{{IPAddress: "198.51.100.1/32", Neighbors: []string{"spines"}}},
+			want:     []string{"herald-198.51.100.1/32-reject"},
+		},
+		{
+			name: "neighbor overrides",
+			prefixes: []config.Prefix{{IPAddress: "2001:db8::1/128", NeighborOverrides: []config.NeighborOverride{
+				{Neighbors: []string{"192.0.2.10"}, MultiExitDescriminator: 10},
+				{Neighbors: []string{"spines"}, Communities: []string{"64600:1"}},
+			}}},
+			want: []string{"herald-2001:db8::1/128-override-0", "herald-2001:db8::1/128-override-1"},
+		},
+		{
+			name: "neighbors and overrides",
+			prefixes: []config.Prefix{{
+				IPAddress:         "198.51.100.1/32",
+				Neighbors:         []string{"192.0.2.10"},
+				NeighborOverrides: []config.NeighborOverride{{Neighbors: []string{"192.0.2.10"}, AsPathPrepend: []uint32{64600}}},
+			}},
+			want: []string{"herald-198.51.100.1/32-override-0", "herald-198.51.100.1/32-reject"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testConfig(tt.prefixes...)
+			c.Neighbors = nil
+			s := newTestSpeaker(t, c)
+			if got := exportStatements(t, s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReloadExportPolicy(t *testing.T) {
+	plain := config.Prefix{IPAddress: "198.51.100.1/32"}
+	selected := config.Prefix{IPAddress: "198.51.100.2/32", Neighbors: []string{"192.0.2.10"}}
+	offline := func(c *config.Config) *config.Config {
+		c.Neighbors = nil
+		return c
+	}
+
+	s := newTestSpeaker(t, offline(testConfig(plain)))
+	if got := exportStatements(t, s); got != nil {
+		t.Fatalf("statements = %v, want none", got)
+	}
+
+	if err := s.Reload(offline(testConfig(plain, selected))); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"herald-198.51.100.2/32-reject"}
+	if got := exportStatements(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements after adding a prefix = %v, want %v", got, want)
+	}
+
+	if err := s.Reload(offline(testConfig(plain))); err != nil {
+		t.Fatal(err)
+	}
+	if got := exportStatements(t, s); got != nil {
+		t.Errorf("statements after removing a prefix = %v, want none", got)
+	}
+}
+
+func TestReloadNeighborFailure(t *testing.T) {
+	old := testConfig()
+	old.Neighbors = nil
+	s := newTestSpeaker(t, old)
+
+	c := testConfig()
+	c.Neighbors = []config.Neighbor{
+		{Address: "192.0.2.10", ASN: 64601},
+		{Address: "spine1", ASN: 64601},
+	}
+	if err := s.Reload(c); err == nil {
+		t.Fatal("Reload succeeded with an invalid neighbor")
+	}
+	if s.Config() != old {
+		t.Error("running configuration replaced by the failed reload")
+	}
+	var peers []string
+	if err := s.Server.ListPeer(s.Context, &api.ListPeerRequest{}, func(p *api.Peer) {
+		peers = append(peers, p.Conf.NeighborAddress)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if peers != nil {
+		t.Errorf("neighbors after the failed reload = %v, want none", peers)
+	}
+}
@@ -0,0 +1,197 @@
+package speaker
+
+import (
+	"fmt"
+	"time"
+
+	api "github.com/osrg/gobgp/v3/api"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ahmet2mir/herald/pkg/address"
+	"github.com/ahmet2mir/herald/pkg/config"
+	"github.com/ahmet2mir/herald/pkg/metrics"
+)
+
+// State is the announcement state of a prefix.
+type State int
+
+const (
+	StateWithdrawn State = iota
+	StateAnnounced
+	StateDegraded
+	StateDraining
+)
+
+var states = []State{StateWithdrawn, StateAnnounced, StateDegraded, StateDraining}
+
+func (st State) String() string {
+	switch st {
+	case StateAnnounced:
+		return "announced"
+	case StateDegraded:
+		return "degraded"
+	case StateDraining:
+		return "draining"
+	default:
+		return "withdrawn"
+	}
+}
+
+// PrefixState is the announcement state of a prefix and when it last
+// changed. Since is zero for prefixes never announced.
+type PrefixState struct {
+	State State
+	Since time.Time
+}
+
+// announcement is the state of a prefix and the path last sent for it.
+type announcement struct {
+	prefix config.Prefix
+	PrefixState
+	path *api.Path
+	// iface is the interface the address of the prefix was added to.
+	iface string
+}
+
+// State returns the announcement state of prefix.
+func (s *Speaker) State(prefix string) PrefixState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.announcements[prefix]; ok {
+		return a.PrefixState
+	}
+	return PrefixState{}
+}
+
+// States returns the announcement state of every prefix herald announced,
+// by prefix.
+func (s *Speaker) States() map[string]PrefixState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := make(map[string]PrefixState, len(s.announcements))
+	for prefix, a := range s.announcements {
+		r[prefix] = a.PrefixState
+	}
+	return r
+}
+
+// announce sends path for p in state unless the same path was already sent,
+// adding the address of p to its interface first. s.mu must be held.
+func (s *Speaker) announce(p config.Prefix, state State, path *api.Path) error {
+	a := s.announcement(p)
+	if p.Interface != "" && (a.State == StateWithdrawn || a.iface != p.Interface) {
+		if err := address.Add(p.Interface, p.IPAddress); err != nil {
+			return fmt.Errorf("not announcing %s: %w", p.IPAddress, err)
+		}
+		zap.S().Info("Added address", "prefix", p.IPAddress, "interface", p.Interface)
+	}
+	if a.iface != "" && a.iface != p.Interface {
+		s.removeAddress(a)
+	}
+	a.iface = p.Interface
+
+	if a.State == state && proto.Equal(a.path, path) {
+		return nil
+	}
+	if _, err := s.Server.AddPath(s.Context, &api.AddPathRequest{Path: path}); err != nil {
+		return err
+	}
+	a.path = path
+	s.transition(a, state)
+	return nil
+}
+
+// withdraw withdraws p unless it is already withdrawn. s.mu must be held.
+func (s *Speaker) withdraw(p config.Prefix) error {
+	a := s.announcement(p)
+	if a.State == StateWithdrawn {
+		return nil
+	}
+	if err := s.Server.DeletePath(s.Context, &api.DeletePathRequest{Path: a.path}); err != nil {
+		return err
+	}
+	a.path = nil
+	s.transition(a, StateWithdrawn)
+	if a.iface != "" && !p.KeepAddress {
+		s.removeAddress(a)
+		a.iface = ""
+	}
+	return nil
+}
+
+// removeAddresses removes the addresses added for the prefixes still
+// announced when herald stops, unless keepAddress is set.
+func (s *Speaker) removeAddresses() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.announcements {
+		if a.iface != "" && !a.prefix.KeepAddress {
+			s.removeAddress(a)
+			a.iface = ""
+		}
+	}
+}
+
+// removeAddress removes the address of the prefix of a from the interface
+// it was added to.
+func (s *Speaker) removeAddress(a *announcement) {
+	if err := address.Delete(a.iface, a.prefix.IPAddress); err != nil {
+		zap.S().Error("Failed to remove address", err)
+		return
+	}
+	zap.S().Info("Removed address", "prefix", a.prefix.IPAddress, "interface", a.iface)
+}
+
+// announcement returns the state of p, tracking it on first use. s.mu must
+// be held.
+func (s *Speaker) announcement(p config.Prefix) *announcement {
+	a, ok := s.announcements[p.IPAddress]
+	if !ok {
+		a = &announcement{prefix: p}
+		s.announcements[p.IPAddress] = a
+		a.setMetrics()
+	}
+	a.prefix = p
+	return a
+}
+
+// transition records that a changed to state, logging it and updating the
+// prefix metrics.
+func (s *Speaker) transition(a *announcement, state State) {
+	p := a.prefix
+	if a.State == state {
+		zap.S().Info("Updated prefix", "prefix", p.IPAddress, "state", state)
+		return
+	}
+	zap.S().Info("Prefix state changed", "prefix", p.IPAddress, "from", a.State, "to", state)
+	a.State = state
+	a.Since = time.Now()
+	a.setMetrics()
+	metrics.PrefixTransitions.WithLabelValues(p.IPAddress, p.Name, state.String()).Inc()
+	metrics.PrefixLastChange.WithLabelValues(p.IPAddress, p.Name).Set(float64(a.Since.Unix()))
+}
+
+// setMetrics sets the prefix gauges from the state of a.
+func (a *announcement) setMetrics() {
+	p := a.prefix
+	for _, st := range states {
+		v := 0.0
+		if st == a.State {
+			v = 1
+		}
+		metrics.PrefixState.WithLabelValues(p.IPAddress, p.Name, st.String()).Set(v)
+	}
+	up := 0.0
+	if a.State != StateWithdrawn {
+		up = 1
+	}
+	metrics.PrefixUp.WithLabelValues(p.IPAddress, p.Name).Set(up)
+	if p.Degraded != nil || a.State == StateDegraded {
+		degraded := 0.0
+		if a.State == StateDegraded {
+			degraded = 1
+		}
+		metrics.PrefixDegraded.WithLabelValues(p.IPAddress, p.Name).Set(degraded)
+	}
+}
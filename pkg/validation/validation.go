@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is a validation failure attached to a configuration path such as
+// "prefixes[0].readinessProbe.periodSeconds".
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Path   string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+		b.WriteString(":")
+	}
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		fmt.Fprintf(&b, "%d:%d:", e.Line, e.Column)
+	case e.Line > 0:
+		fmt.Fprintf(&b, "%d:", e.Line)
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if e.Path != "" {
+		b.WriteString(e.Path)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors collects every validation failure so they can be reported at once.
+type Errors []*Error
+
+// Add records err for path.
+func (es *Errors) Add(path string, err error) {
+	*es = append(*es, &Error{Path: path, Err: err})
+}
+
+// Addf records a formatted error for path.
+func (es *Errors) Addf(path, format string, args ...any) {
+	es.Add(path, fmt.Errorf(format, args...))
+}
+
+// Err returns nil when no error was recorded, the collection otherwise.
+func (es Errors) Err() error {
+	if len(es) == 0 {
+		return nil
+	}
+	return es
+}
+
+// Unique returns es without the errors repeating the position and message
+// of a previous one, as happens when a shared definition is invalid.
+func (es Errors) Unique() Errors {
+	type key struct {
+		file         string
+		line, column int
+		msg          string
+	}
+	seen := map[key]bool{}
+	unique := make(Errors, 0, len(es))
+	for _, e := range es {
+		k := key{e.File, e.Line, e.Column, e.Err.Error()}
+		if e.Line > 0 && seen[k] {
+			continue
+		}
+		seen[k] = true
+		unique = append(unique, e)
+	}
+	return unique
+}
+
+func (es Errors) Error() string {
+	lines := make([]string, 0, len(es))
+	for _, e := range es {
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Field returns the path of field name below path.
+func Field(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// Index returns the path of element i of the list at path.
+func Index(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
@@ -23,7 +23,9 @@ Wants=network-online.target
 Type=simple
 User=herald
 Group=herald
-ExecStart=/usr/bin/herald --config /etc/herald/config.yaml
+ExecStartPre=/usr/bin/herald validate --quiet --config /etc/herald/config.yaml
+ExecStart=/usr/bin/herald run --config /etc/herald/config.yaml
+ExecReload=/bin/kill -HUP $MAINPID
 Restart=always
 RestartSec=5
 StandardOutput=journal